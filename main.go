@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,6 +10,14 @@ import (
 	"github.com/fred1268/okapi/testing"
 )
 
+const (
+	// exitTestsFailed is used when at least one test failed.
+	exitTestsFailed = 1
+	// exitCannotRun is used when okapi could not run the tests
+	// (invalid configuration, unreachable server, etc.).
+	exitCannotRun = 2
+)
+
 func help() {
 	fmt.Println("okapi is a tool to help make API tests as easy as table driven tests.")
 	fmt.Println()
@@ -29,11 +38,17 @@ func help() {
 	fmt.Println("\t--user-agent (default okapi UA):\t\t\tset the default user agent")
 	fmt.Println("\t--content-type (default 'application/json'):\t\tset the default content type for requests")
 	fmt.Println("\t--accept (default 'application/json'):\t\t\tset the default accept header for responses")
+	fmt.Println("\t--json (default no):\t\t\t\t\temit test events as JSON objects, one per line")
+	fmt.Println("\t--report (default none):\t\t\t\twrite a report of the results (junit=<path>)")
+	fmt.Println("\t--update-snapshots (default no):\t\t\twrite the responses of @snapshot tests to their snapshots")
 	fmt.Println()
 	fmt.Println("The parameters are:")
 	fmt.Println()
 	fmt.Println("\ttest_directory:\t\t\t\t\t\tpoint to the directory where all the test files are located")
 	fmt.Println()
+	fmt.Println("The exit status is 0 if all tests passed, 1 if at least one test failed and 2 if okapi could not run,")
+	fmt.Println("or if at least one test could not run (unreachable server, etc.), even if other tests failed.")
+	fmt.Println()
 	fmt.Println("More information (and source code) on: https://github.com/fred1268/okapi")
 	fmt.Println()
 }
@@ -45,9 +60,20 @@ func main() {
 	}
 	cfg, err := testing.LoadConfig(os.Args)
 	if err != nil {
-		log.Fatalf("Cannot read command line parameters: %s\n", err)
+		log.Printf("Cannot read command line parameters: %s\n", err)
+		os.Exit(exitCannotRun)
 	}
-	if err := testing.Run(context.Background(), cfg); err != nil {
-		log.Fatalf("Cannot run tests: %s\n", err)
+	result, err := testing.Run(context.Background(), cfg)
+	if err != nil {
+		// tests which could not run take precedence over failures
+		if errors.Is(err, testing.ErrTestsErrored) {
+			log.Printf("Cannot run %d test(s)\n", result.Errored)
+			os.Exit(exitCannotRun)
+		}
+		if errors.Is(err, testing.ErrTestsFailed) {
+			os.Exit(exitTestsFailed)
+		}
+		log.Printf("Cannot run tests: %s\n", err)
+		os.Exit(exitCannotRun)
 	}
 }
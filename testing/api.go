@@ -1,12 +1,65 @@
 package testing
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"regexp"
 	"strings"
+	"time"
 
+	ijson "github.com/fred1268/okapi/testing/internal/json"
 	"github.com/fred1268/okapi/testing/internal/os"
+	ixml "github.com/fred1268/okapi/testing/internal/xml"
 )
 
+// Assertion represents a check on a specific field of
+// a JSON, XML or HTML response.
+type Assertion struct {
+	// Path represents the JSONPath-like path of the field
+	// (e.g. $.data.items[0].price or $.items.length()) of a
+	// JSON response, or the XPath of the element or attribute
+	// (e.g. /rss/channel/item[1]/title or //item/@id) of an
+	// XML or HTML response.
+	Path string
+	// Operator represents the check to perform: ==, !=, <, <=,
+	// >, >=, contains, matches, exists, notExists or type (JSON
+	// responses only).
+	Operator string
+	// Value represents the value to check against. It is
+	// ignored by the exists and notExists operators.
+	Value any
+}
+
+// Tolerance represents how much two numbers can differ and
+// still be considered equal. The difference must be lower than
+// or equal to Absolute + Relative * |expected|.
+type Tolerance struct {
+	// Absolute represents the absolute tolerance (e.g. 0.01).
+	Absolute float64
+	// Relative represents the relative tolerance (e.g. 0.001
+	// for 0.1%).
+	Relative float64
+}
+
+// PathOptions represents comparison options applying to a
+// specific JSONPath-like path of the response (and its
+// children). A nil field means the option is inherited.
+type PathOptions struct {
+	// CaseInsensitive makes the comparison of strings case
+	// insensitive.
+	CaseInsensitive *bool
+	// ArrayMode represents how arrays are compared (contains,
+	// exact, ordered or set).
+	ArrayMode string
+	// Strict makes objects mismatch if the response contains
+	// fields which are not expected.
+	Strict *bool
+	// Tolerance represents how much numbers can differ.
+	Tolerance *Tolerance
+}
+
 // APIRequest contains all information required to run a test.
 type APIRequest struct {
 	// Name represents the name of the test.
@@ -22,14 +75,51 @@ type APIRequest struct {
 	// It should be relative to the server's Host.
 	Endpoint string
 	// Headers represents additional headers to add
-	// to the request.
+	// to the request. They override the server's and
+	// the file's headers, and an empty value removes
+	// the header altogether.
 	Headers map[string]string
 	// URLParams represents additional query parameters
-	// that will be send with the request.
-	URLParams map[string]string
+	// that will be send with the request, in order, after
+	// the ones of the Endpoint, if any.
+	URLParams URLParams
 	// Payload represents the payload provided with some
 	// methods (POST, PUT, etc.) to the request.
 	Payload string
+	// ContentType represents the content type of the payload.
+	// It overrides the server's content type.
+	ContentType string
+	// Accept represents the content types accepted for the
+	// response. It overrides the server's accept.
+	Accept string
+	// Form represents the fields of an URL encoded form
+	// (application/x-www-form-urlencoded) sent as the
+	// payload of the request.
+	Form map[string]string
+	// Multipart represents the fields and files of a
+	// multipart/form-data form sent as the payload of
+	// the request.
+	Multipart *Multipart
+	// Timeout represents the timeout (e.g. 5s) of each attempt
+	// of the request. It overrides the server's timeout.
+	Timeout string
+	// Retries represents the number of times the request is
+	// retried, according to RetryOn. It overrides the server's
+	// retries.
+	Retries *int
+	// RetryOn represents the status codes (e.g. 503 or 5xx)
+	// and/or "connection" (for connection errors and timeouts)
+	// for which the request is retried. It overrides the
+	// server's retry on, and defaults to connection, 502, 503
+	// and 504.
+	RetryOn RetryOn
+	// Backoff represents the delay between two attempts of
+	// the request. It overrides the server's backoff.
+	Backoff *Backoff
+	// WaitUntil makes okapi send the request again until
+	// the response matches Expected, or until its timeout
+	// expires, which is handy for asynchronous operations.
+	WaitUntil *WaitUntil
 	// Expected represents the expected APIResponse if
 	// everything goes according to the plan. The Logs
 	// field is ignored in this context.
@@ -47,8 +137,9 @@ type APIRequest struct {
 	Skip bool
 	// Debug will make okapi output test debugging
 	// information to ease troubleshooting errors
-	Debug  bool
-	atFile bool
+	Debug       bool
+	atFile      bool
+	fileHeaders map[string]string
 }
 
 // APIResponse contains information about the response from
@@ -57,13 +148,88 @@ type APIResponse struct {
 	// StatusCode represents the HTTP Status Code returned
 	// by the server.
 	StatusCode int
+	// StatusCodes represents the acceptable status codes (e.g.
+	// 204) or status classes (e.g. 2xx) when statuscode is set
+	// to a list or a class. Only used in expectations, and set
+	// from statuscode (never decoded on its own).
+	StatusCodes []string `json:"-"`
 	// Response represents the payload (response) returned
 	// by the server.
 	Response string
+	// Headers represents the headers returned by the server.
+	// In expectations, the values can either be the exact
+	// value of the header or a regular expression.
+	Headers map[string]string
+	// Assertions represents checks on specific fields of a
+	// JSON response. Only used in expectations.
+	Assertions []*Assertion
+	// CaseInsensitive makes the comparison of the response
+	// case insensitive. Only used in expectations.
+	CaseInsensitive bool
+	// ArrayMode represents how arrays are compared: contains
+	// (default), exact, ordered or set. Only used in expectations.
+	ArrayMode string
+	// Strict makes objects mismatch if the response contains
+	// fields which are not expected. Only used in expectations.
+	Strict bool
+	// Tolerance represents how much numbers can differ. By
+	// default, numbers must be strictly equal. Only used in
+	// expectations.
+	Tolerance *Tolerance
+	// Paths represents comparison options for specific paths
+	// of the response. Only used in expectations.
+	Paths map[string]*PathOptions
+	// Absent represents the JSONPath-like paths which must not
+	// match any value of a JSON response. Only used in
+	// expectations.
+	Absent []string
+	// NotContains represents the regular expressions which must
+	// not match the response. Only used in expectations.
+	NotContains []string
+	// SHA256 represents the hexadecimal SHA-256 hash of the
+	// response. Only used in expectations.
+	SHA256 string
+	// Size represents the size of the response, in bytes. Only
+	// used in expectations.
+	Size *int
+	// MinSize represents the minimum size of the response, in
+	// bytes. Only used in expectations.
+	MinSize int
+	// File represents the file, relative to the test directory,
+	// the response must be identical to, byte for byte. Only used
+	// in expectations.
+	File string
+	// Format represents the format of the response: json, xml,
+	// html or text. By default, it is deduced from the Content-Type
+	// of the response. Only used in expectations.
+	Format string
+	// Schema represents a JSON Schema (draft 2020-12) the
+	// response must be valid against. Only used in expectations.
+	Schema string
+	// Ignore represents the JSONPath-like paths of the values
+	// (e.g. timestamps) which are removed from both the expected
+	// and the actual responses before comparing them, and from
+	// snapshots. Only used in expectations.
+	Ignore []string
+	// MaxDuration represents the maximum time (e.g. 500ms) the
+	// server can take to respond. It overrides the server's
+	// default. Only used in expectations.
+	MaxDuration string
 	// Logs represents okapi's logs which are grouped later
 	// on to be nicely displayed even in parallel mode.
-	Logs   []string
-	atFile bool
+	Logs []string
+	// attempts represents the logs of the attempts of the
+	// request (retries and polling), which are only added
+	// to Logs in verbose mode or when the test fails.
+	attempts []string
+	atFile   bool
+	snapshot bool
+	content  string
+	request  *http.Request
+	duration time.Duration
+	// credentials represents the request headers set by the
+	// authentication, which must not be disclosed.
+	credentials map[string]bool
 }
 
 func (a *APIRequest) validate() error {
@@ -76,11 +242,220 @@ func (a *APIRequest) validate() error {
 	if a.Method == "" || a.Endpoint == "" || a.Expected == nil {
 		return fmt.Errorf("empty method, endpoint or expectations")
 	}
+	for _, pattern := range a.Expected.StatusCodes {
+		if err := validStatusCode(pattern); err != nil {
+			return err
+		}
+	}
+	if a.Payload != "" && (a.Form != nil || a.Multipart != nil) || a.Form != nil && a.Multipart != nil {
+		return fmt.Errorf("only one of payload, form or multipart can be provided")
+	}
+	if a.Multipart != nil {
+		if err := a.Multipart.validate(); err != nil {
+			return err
+		}
+	}
+	if a.Timeout != "" {
+		if _, err := time.ParseDuration(a.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	if err := validateRetryPolicy(a.Retries, a.RetryOn, a.Backoff); err != nil {
+		return err
+	}
+	if a.WaitUntil != nil {
+		if err := a.WaitUntil.validate(); err != nil {
+			return err
+		}
+	}
+	if err := a.Expected.compareOptions().Validate(); err != nil {
+		return fmt.Errorf("invalid comparison options: %w", err)
+	}
+	if a.Expected.SHA256 != "" {
+		if sum, err := hex.DecodeString(a.Expected.SHA256); err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("invalid sha256 '%s'", a.Expected.SHA256)
+		}
+	}
+	if a.Expected.Size != nil && *a.Expected.Size < 0 || a.Expected.MinSize < 0 {
+		return fmt.Errorf("invalid size")
+	}
+	switch a.Expected.Format {
+	case "", formatJSON, formatXML, formatHTML, formatText:
+	default:
+		return fmt.Errorf("invalid format '%s'", a.Expected.Format)
+	}
+	if a.Expected.Format != "" {
+		if errs := a.Expected.unsupportedChecks(a.Expected.Format); len(errs) != 0 {
+			return errs[0]
+		}
+	}
+	for _, assertion := range a.Expected.Assertions {
+		if assertion.isXPath() {
+			if err := assertion.xml().Validate(); err != nil {
+				return fmt.Errorf("invalid assertion: %w", err)
+			}
+		} else if err := assertion.internal().Validate(); err != nil {
+			return fmt.Errorf("invalid assertion: %w", err)
+		}
+	}
+	for _, path := range a.Expected.Absent {
+		if err := ijson.ValidatePath(path); err != nil {
+			return fmt.Errorf("invalid absent path: %w", err)
+		}
+	}
+	for _, key := range ijson.SortedKeys(a.Expected.Headers) {
+		if _, err := regexp.Compile(a.Expected.Headers[key]); err != nil {
+			return fmt.Errorf("invalid header %s regular expression: %w", key, err)
+		}
+	}
+	for _, pattern := range a.Expected.NotContains {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid not contains regular expression: %w", err)
+		}
+	}
+	for _, path := range a.Expected.Ignore {
+		if err := ijson.ValidatePath(path); err != nil {
+			return fmt.Errorf("invalid ignored path: %w", err)
+		}
+	}
+	if a.Expected.MaxDuration != "" {
+		if _, err := time.ParseDuration(a.Expected.MaxDuration); err != nil {
+			return fmt.Errorf("invalid max duration: %w", err)
+		}
+	}
 	a.Endpoint = os.SubstituteEnvironmentVariable(a.Endpoint)
 	a.Payload = os.SubstituteEnvironmentVariable(a.Payload)
+	for key, value := range a.Form {
+		a.Form[key] = os.SubstituteEnvironmentVariable(value)
+	}
+	if a.Multipart != nil {
+		for key, value := range a.Multipart.Fields {
+			a.Multipart.Fields[key] = os.SubstituteEnvironmentVariable(value)
+		}
+	}
 	return nil
 }
 
 func (a *APIRequest) hasFileDepencies() bool {
 	return a.atFile || a.Expected.atFile
 }
+
+func (a *Assertion) internal() *ijson.Assertion {
+	return &ijson.Assertion{Path: a.Path, Operator: a.Operator, Value: a.Value}
+}
+
+func (a *Assertion) isXPath() bool {
+	return strings.HasPrefix(a.Path, "/") || strings.HasPrefix(a.Path, "count(")
+}
+
+func (a *Assertion) xml() *ixml.Assertion {
+	return &ixml.Assertion{Path: a.Path, Operator: a.Operator, Value: a.Value}
+}
+
+func (a *APIResponse) assertions() []*ijson.Assertion {
+	assertions := make([]*ijson.Assertion, 0, len(a.Assertions))
+	for _, assertion := range a.Assertions {
+		if !assertion.isXPath() {
+			assertions = append(assertions, assertion.internal())
+		}
+	}
+	return assertions
+}
+
+func (a *APIResponse) xmlAssertions() []*ixml.Assertion {
+	var assertions []*ixml.Assertion
+	for _, assertion := range a.Assertions {
+		if assertion.isXPath() {
+			assertions = append(assertions, assertion.xml())
+		}
+	}
+	return assertions
+}
+
+// unsupportedChecks returns the assertions, absent paths and
+// schema which cannot apply to a response of the given format.
+func (a *APIResponse) unsupportedChecks(format string) []error {
+	var errs []error
+	for _, assertion := range a.Assertions {
+		switch {
+		case assertion.isXPath() && format != formatXML && format != formatHTML:
+			errs = append(errs, fmt.Errorf("%w: XPath assertion on %s requires an xml or html response, got %s",
+				ErrUnsupportedCheck, assertion.Path, format))
+		case !assertion.isXPath() && format != formatJSON:
+			errs = append(errs, fmt.Errorf("%w: JSONPath assertion on %s requires a json response, got %s",
+				ErrUnsupportedCheck, assertion.Path, format))
+		}
+	}
+	if format != formatJSON {
+		for _, path := range a.Absent {
+			errs = append(errs, fmt.Errorf("%w: absent path %s requires a json response, got %s",
+				ErrUnsupportedCheck, path, format))
+		}
+		if a.Schema != "" {
+			errs = append(errs, fmt.Errorf("%w: schema requires a json response, got %s", ErrUnsupportedCheck, format))
+		}
+	}
+	return errs
+}
+
+const (
+	formatJSON = "json"
+	formatXML  = "xml"
+	formatHTML = "html"
+	formatText = "text"
+)
+
+// format returns the expected format of the response, or the
+// one deduced from the Content-Type of the response or, if the
+// server did not provide one, from the Accept of the request.
+func (a *APIResponse) format(response *APIResponse) string {
+	if a.Format != "" {
+		return a.Format
+	}
+	contentType := response.Headers["Content-Type"]
+	if contentType == "" && response.request != nil {
+		contentType = response.request.Header.Get("Accept")
+	}
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		return formatJSON
+	case strings.Contains(contentType, "html"):
+		return formatHTML
+	case strings.Contains(contentType, "xml"):
+		return formatXML
+	case strings.HasPrefix(contentType, "text/"):
+		return formatText
+	}
+	return formatJSON
+}
+
+func (a *APIResponse) compareOptions() *ijson.Options {
+	options := &ijson.Options{
+		CaseInsensitive: a.CaseInsensitive,
+		ArrayMode:       a.ArrayMode,
+		Strict:          a.Strict,
+		Tolerance:       a.Tolerance.internal(),
+	}
+	if len(a.Paths) != 0 {
+		options.Paths = make(map[string]*ijson.PathOptions)
+		for path, pathOptions := range a.Paths {
+			if pathOptions != nil {
+				options.Paths[path] = &ijson.PathOptions{
+					CaseInsensitive: pathOptions.CaseInsensitive,
+					ArrayMode:       pathOptions.ArrayMode,
+					Strict:          pathOptions.Strict,
+					Tolerance:       pathOptions.Tolerance.internal(),
+				}
+			}
+		}
+	}
+	return options
+}
+
+func (t *Tolerance) internal() *ijson.Tolerance {
+	if t == nil {
+		return nil
+	}
+	return &ijson.Tolerance{Absolute: t.Absolute, Relative: t.Relative}
+}
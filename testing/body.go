@@ -0,0 +1,71 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxBinaryDisplayLength = 32
+
+// isBinary returns true if the body is not valid UTF-8, or
+// contains control characters other than whitespaces.
+func isBinary(body string) bool {
+	if !utf8.ValidString(body) {
+		return true
+	}
+	for _, r := range body {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// printable returns the body as is if it is text, or a short
+// hexadecimal representation of it if it is binary, so that it
+// can safely be displayed.
+func printable(body string) string {
+	if !isBinary(body) {
+		return body
+	}
+	if len(body) <= maxBinaryDisplayLength {
+		return fmt.Sprintf("(binary, %d bytes) %s", len(body), hex.EncodeToString([]byte(body)))
+	}
+	return fmt.Sprintf("(binary, %d bytes) %s...", len(body),
+		hex.EncodeToString([]byte(body[:maxBinaryDisplayLength])))
+}
+
+// checkBody checks the hash, the size and the content of the
+// response body, byte for byte.
+func checkBody(expected, response *APIResponse) error {
+	var errs []error
+	if expected.SHA256 != "" {
+		sum := sha256.Sum256([]byte(response.Response))
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(expected.SHA256, got) {
+			errs = append(errs, fmt.Errorf("%w: sha256: wanted %s, got %s", ErrBodyMismatched, expected.SHA256, got))
+		}
+	}
+	if expected.Size != nil && len(response.Response) != *expected.Size {
+		errs = append(errs, fmt.Errorf("%w: size: wanted %d bytes, got %d", ErrBodyMismatched, *expected.Size,
+			len(response.Response)))
+	}
+	if len(response.Response) < expected.MinSize {
+		errs = append(errs, fmt.Errorf("%w: size: wanted at least %d bytes, got %d", ErrBodyMismatched,
+			expected.MinSize, len(response.Response)))
+	}
+	if expected.File != "" && expected.content != response.Response {
+		wanted, got := []byte(expected.content), []byte(response.Response)
+		offset := 0
+		for offset < len(wanted) && offset < len(got) && wanted[offset] == got[offset] {
+			offset++
+		}
+		errs = append(errs, fmt.Errorf("%w: content differs from '%s' at byte %d (wanted %d bytes, got %d)",
+			ErrBodyMismatched, expected.File, offset, len(wanted), len(got)))
+	}
+	return errors.Join(errs...)
+}
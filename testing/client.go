@@ -1,7 +1,6 @@
 package testing
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,10 +9,13 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
 	ijson "github.com/fred1268/okapi/testing/internal/json"
+	"github.com/fred1268/okapi/testing/internal/log"
+	ixml "github.com/fred1268/okapi/testing/internal/xml"
 )
 
 // Client represent an API Client for the specified server.
@@ -34,16 +36,14 @@ func NewClient(config *ServerConfig) *Client {
 	client := &Client{
 		config: config,
 		client: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
 			Transport: &http.Transport{
 				DialContext: (&net.Dialer{
 					Timeout: 5 * time.Second,
 				}).DialContext,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ResponseHeaderTimeout: 10 * time.Second,
-				MaxIdleConns:          100,
-				MaxConnsPerHost:       100,
-				MaxIdleConnsPerHost:   100,
+				TLSHandshakeTimeout: 10 * time.Second,
+				MaxIdleConns:        100,
+				MaxConnsPerHost:     100,
+				MaxIdleConnsPerHost: 100,
 			},
 		},
 	}
@@ -60,16 +60,14 @@ func (c *Client) Clone() *Client {
 		cookie: &cookie,
 		jwt:    c.jwt,
 		client: &http.Client{
-			Timeout: time.Duration(c.config.Timeout) * time.Second,
 			Transport: &http.Transport{
 				DialContext: (&net.Dialer{
 					Timeout: 5 * time.Second,
 				}).DialContext,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ResponseHeaderTimeout: 10 * time.Second,
-				MaxIdleConns:          100,
-				MaxConnsPerHost:       100,
-				MaxIdleConnsPerHost:   100,
+				TLSHandshakeTimeout: 10 * time.Second,
+				MaxIdleConns:        100,
+				MaxConnsPerHost:     100,
+				MaxIdleConnsPerHost: 100,
 			},
 		},
 	}
@@ -77,24 +75,24 @@ func (c *Client) Clone() *Client {
 
 func (c *Client) buildEndpointURL(ctx context.Context, apiRequest *APIRequest) (string, error) {
 	var err error
-	addr := apiRequest.Endpoint
+	// the query of the endpoint is kept as is (e.g. signed URLs)
+	addr, query, _ := strings.Cut(apiRequest.Endpoint, "?")
 	if !strings.Contains(apiRequest.Endpoint, "://") {
-		addr, err = url.JoinPath(c.config.Host, apiRequest.Endpoint)
+		addr, err = url.JoinPath(c.config.Host, addr)
 		if err != nil {
 			return "", err
 		}
 	}
 	if len(apiRequest.URLParams) != 0 {
-		first := true
-		for key, value := range apiRequest.URLParams {
-			if first {
-				addr = fmt.Sprintf("%s?%s=%s", addr, url.QueryEscape(key), url.QueryEscape(value))
-				first = false
-			} else {
-				addr = fmt.Sprintf("%s&%s=%s", addr, url.QueryEscape(key), url.QueryEscape(value))
-			}
+		if query != "" {
+			query = fmt.Sprintf("%s&%s", query, apiRequest.URLParams.Encode())
+		} else {
+			query = apiRequest.URLParams.Encode()
 		}
 	}
+	if query != "" {
+		addr = fmt.Sprintf("%s?%s", addr, query)
+	}
 	return addr, nil
 }
 
@@ -108,52 +106,39 @@ func (c *Client) getRequest(ctx context.Context, apiRequest *APIRequest, apiResp
 		apiResponse.Logs = append(apiResponse.Logs, "API Request:\n")
 		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  URL: %s\n", addr))
 		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  Method: %s\n", apiRequest.Method))
-		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  Payload: %s\n", apiRequest.Payload))
+		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  Payload: %s\n", apiRequest.describePayload()))
 		apiResponse.Logs = append(apiResponse.Logs, "  Headers:\n")
 	}
-	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(apiRequest.Method), addr,
-		bytes.NewBufferString(apiRequest.Payload))
+	body, contentType, err := apiRequest.body()
 	if err != nil {
 		return nil, err
 	}
-	if c.config.Auth != nil && c.config.Auth.APIKey != nil {
-		if apiRequest.Debug {
-			apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    %s: %s\n", c.config.Auth.APIKey.Header,
-				c.config.Auth.APIKey.APIKey))
-		}
-		req.Header.Set(c.config.Auth.APIKey.Header, c.config.Auth.APIKey.APIKey)
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(apiRequest.Method), addr, body)
+	if err != nil {
+		return nil, err
 	}
-	if c.config.UserAgent != "" {
+	headers, removed := c.requestHeaders(apiRequest, contentType)
+	for _, key := range ijson.SortedKeys(headers) {
 		if apiRequest.Debug {
-			apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    User-Agent: %s\n", c.config.UserAgent))
+			apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    %s: %s (%s)\n", key, headers[key].value,
+				headers[key].source))
+		}
+		req.Header.Set(key, headers[key].value)
+		if headers[key].source == sourceAuth {
+			if apiResponse.credentials == nil {
+				apiResponse.credentials = make(map[string]bool)
+			}
+			apiResponse.credentials[key] = true
 		}
-		req.Header.Add("User-Agent", c.config.UserAgent)
 	}
-	if c.jwt != "" {
-		if apiRequest.Debug {
-			apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    Authorization: Bearer %s\n", c.jwt))
+	if apiRequest.Debug {
+		for _, key := range ijson.SortedKeys(removed) {
+			apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    %s: removed (%s)\n", key, removed[key]))
 		}
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.jwt))
 	}
 	if c.cookie != nil {
 		req.AddCookie(c.cookie)
 	}
-	req.Header.Add("X-okapi-testname", apiRequest.Name)
-	if len(apiRequest.Headers) != 0 {
-		for key, value := range apiRequest.Headers {
-			if apiRequest.Debug {
-				apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    %s: %s\n", key, value))
-			}
-			req.Header.Add(key, value)
-		}
-	} else {
-		for key, value := range c.config.Headers {
-			if apiRequest.Debug {
-				apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    %s: %s\n", key, value))
-			}
-			req.Header.Add(key, value)
-		}
-	}
 	return req, nil
 }
 
@@ -179,11 +164,20 @@ func (c *Client) captureJWT(response string) error {
 
 func (c *Client) call(ctx context.Context, apiRequest *APIRequest) (apiResponse *APIResponse, err error) {
 	apiResponse = &APIResponse{}
+	// the timeout is set on the context rather than on the
+	// http.Client, since it can be overridden by each test
+	if timeout := c.timeout(apiRequest); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	var req *http.Request
 	req, err = c.getRequest(ctx, apiRequest, apiResponse)
 	if err != nil {
 		return
 	}
+	apiResponse.request = req
+	start := time.Now()
 	var resp *http.Response
 	resp, err = c.client.Do(req)
 	if err != nil {
@@ -206,9 +200,19 @@ func (c *Client) call(ctx context.Context, apiRequest *APIRequest) (apiResponse
 	if err != nil {
 		return
 	}
+	apiResponse.duration = time.Since(start)
+	apiResponse.Headers = make(map[string]string)
+	for key, values := range resp.Header {
+		apiResponse.Headers[key] = strings.Join(values, ", ")
+	}
 	if apiRequest.Debug {
 		apiResponse.Logs = append(apiResponse.Logs, "API Response:\n")
-		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  Response: %s", string(res)))
+		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  Duration: %s\n", apiResponse.duration))
+		apiResponse.Logs = append(apiResponse.Logs, "  Headers:\n")
+		for _, key := range ijson.SortedKeys(apiResponse.Headers) {
+			apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("    %s: %s\n", key, apiResponse.Headers[key]))
+		}
+		apiResponse.Logs = append(apiResponse.Logs, fmt.Sprintf("  Response: %s", printable(string(res))))
 	}
 	if c.jwt == "" && c.config.Auth != nil && c.config.Auth.Session != nil && c.config.Auth.Session.JWT != "" {
 		switch c.config.Auth.Session.JWT {
@@ -236,7 +240,7 @@ func (c *Client) Connect(ctx context.Context) (*APIResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	if result.StatusCode != c.config.Auth.Login.Expected.StatusCode {
+	if !c.config.Auth.Login.Expected.acceptsStatusCode(result.StatusCode) {
 		return result, ErrStatusCodeMismatched
 	}
 	return result, nil
@@ -255,6 +259,9 @@ func (c *Client) Test(ctx context.Context, apiRequest *APIRequest, verbose bool)
 		if response == nil {
 			response = &APIResponse{}
 		}
+		if verbose || err != nil {
+			response.Logs = append(response.Logs, response.attempts...)
+		}
 		if err == nil {
 			if verbose {
 				result := "PASS"
@@ -267,25 +274,211 @@ func (c *Client) Test(ctx context.Context, apiRequest *APIRequest, verbose bool)
 		} else {
 			response.Logs = append(response.Logs, fmt.Sprintf("    --- FAIL:\t%s (%0.2fs)\n", apiRequest.Name,
 				time.Since(start).Seconds()))
-			response.Logs = append(response.Logs, fmt.Sprintf("    wanted: '%s' (%d), got '%s' (%d)\n",
-				apiRequest.Expected.Response, apiRequest.Expected.StatusCode, strings.Trim(response.Response, "\n"),
-				response.StatusCode))
+			if mismatches := differences(err); len(mismatches) != 0 {
+				response.Logs = append(response.Logs, fmt.Sprintf("    wanted: (%s), got (%d), %d difference(s):\n",
+					apiRequest.Expected.statusCodes(), response.StatusCode, len(mismatches)))
+				for _, m := range mismatches {
+					response.Logs = append(response.Logs, fmt.Sprintf("      %s: %s, wanted %s, got %s\n", m.Location(),
+						m.Reason, log.Green(m.Wanted), log.Red(m.Got)))
+				}
+			} else {
+				response.Logs = append(response.Logs, fmt.Sprintf("    wanted: '%s' (%s), got '%s' (%d)\n",
+					apiRequest.Expected.Response, apiRequest.Expected.statusCodes(), strings.Trim(printable(response.Response), "\n"),
+					response.StatusCode))
+			}
+			for _, key := range ijson.SortedKeys(apiRequest.Expected.Headers) {
+				response.Logs = append(response.Logs, fmt.Sprintf("    header %s: '%s'\n", key,
+					response.Headers[http.CanonicalHeaderKey(key)]))
+			}
+			for _, failure := range failures(err) {
+				response.Logs = append(response.Logs, fmt.Sprintf("    %s\n", failure))
+			}
 		}
 	}()
 	if apiRequest.Skip {
 		return
 	}
-	response, err = c.call(ctx, apiRequest)
-	if err != nil {
+	if apiRequest.WaitUntil != nil {
+		response, err = c.poll(ctx, apiRequest)
 		return
 	}
-	if response.StatusCode != apiRequest.Expected.StatusCode {
-		err = ErrStatusCodeMismatched
+	response, err = c.callWithRetries(ctx, apiRequest)
+	if err != nil {
 		return
 	}
-	err = ijson.CompareJSONStrings(apiRequest.Expected.Response, response.Response)
+	err = c.check(apiRequest.Expected, response)
+	return
+}
+
+// differences returns the differences between the expected
+// and the actual JSON, XML or HTML responses, if any.
+func differences(err error) []*ijson.Mismatch {
+	var jsonMismatch *ijson.MismatchError
+	if errors.As(err, &jsonMismatch) {
+		return jsonMismatch.Mismatches
+	}
+	var xmlMismatch *ixml.MismatchError
+	if errors.As(err, &xmlMismatch) {
+		return xmlMismatch.Mismatches
+	}
+	return nil
+}
+
+// failures returns the detailed reasons why a test failed.
+func failures(err error) []string {
+	var lines []string
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			lines = append(lines, failures(e)...)
+		}
+		return lines
+	}
+	if errors.Is(err, ijson.ErrAssertionFailed) || errors.Is(err, ixml.ErrAssertionFailed) ||
+		errors.Is(err, ijson.ErrSchemaViolated) || errors.Is(err, ErrHeaderMismatched) || errors.Is(err, ErrTooSlow) ||
+		errors.Is(err, ErrBodyMismatched) || errors.Is(err, ErrUnsupportedCheck) {
+		lines = append(lines, err.Error())
+	}
+	return lines
+}
+
+func checkHeaders(expected, got map[string]string) error {
+	var errs []error
+	for _, key := range ijson.SortedKeys(expected) {
+		wanted := expected[key]
+		value, found := got[http.CanonicalHeaderKey(key)]
+		if !found {
+			errs = append(errs, fmt.Errorf("%w: %s: wanted '%s', got none", ErrHeaderMismatched, key, wanted))
+			continue
+		}
+		if value == wanted {
+			continue
+		}
+		if matched, err := regexp.MatchString(wanted, value); err != nil || !matched {
+			errs = append(errs, fmt.Errorf("%w: %s: wanted '%s', got '%s'", ErrHeaderMismatched, key, wanted, value))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) check(expected, response *APIResponse) error {
+	if !expected.acceptsStatusCode(response.StatusCode) {
+		return ErrStatusCodeMismatched
+	}
+	if err := checkHeaders(expected.Headers, response.Headers); err != nil {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	if err := checkBody(expected, response); err != nil {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	format := expected.format(response)
+	if errs := expected.unsupportedChecks(format); len(errs) != 0 {
+		return errors.Join(append(errs, ErrResponseMismatched)...)
+	}
+	var err error
+	switch format {
+	case formatXML, formatHTML:
+		err = checkXML(expected, response, format == formatHTML)
+	case formatText:
+		err = checkText(expected, response)
+	default:
+		err = checkJSON(expected, response)
+	}
+	if err != nil {
+		return err
+	}
+	err = ijson.CheckNotContains(response.Response, expected.NotContains)
+	if errors.Is(err, ijson.ErrAssertionFailed) {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.checkDuration(expected, response); err != nil {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	return nil
+}
+
+// checkJSON checks the JSON response, using the JSONPath-like
+// assertions and the JSON Schema.
+func checkJSON(expected, response *APIResponse) error {
+	wanted, got := expected.Response, response.Response
+	if len(expected.Ignore) != 0 {
+		if redacted, err := ijson.Redact(wanted, expected.Ignore); err == nil {
+			wanted = redacted
+		}
+		if redacted, err := ijson.Redact(got, expected.Ignore); err == nil {
+			got = redacted
+		}
+	}
+	err := ijson.CompareJSONStrings(wanted, got, expected.compareOptions())
 	if errors.Is(err, ijson.ErrJSONMismatched) {
-		err = errors.Join(err, ErrResponseMismatched)
+		return errors.Join(err, ErrResponseMismatched)
 	}
-	return
+	if err != nil {
+		return err
+	}
+	err = errors.Join(ijson.CheckAssertions(response.Response, expected.assertions()),
+		ijson.CheckAbsent(response.Response, expected.Absent))
+	if errors.Is(err, ijson.ErrAssertionFailed) {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	if err != nil {
+		return err
+	}
+	err = ijson.ValidateSchema(expected.Schema, response.Response)
+	if errors.Is(err, ijson.ErrSchemaViolated) {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	return err
+}
+
+// checkXML checks the XML or HTML response, using the XPath
+// assertions only.
+func checkXML(expected, response *APIResponse, html bool) error {
+	// unless the format is explicit, the expected response can
+	// also be plain text or a regular expression
+	options := &ixml.Options{CaseInsensitive: expected.CaseInsensitive, HTML: html, TextFallback: expected.Format == ""}
+	err := ixml.CompareXMLStrings(expected.Response, response.Response, options)
+	if errors.Is(err, ixml.ErrXMLMismatched) {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	if err != nil {
+		return err
+	}
+	err = ixml.CheckAssertions(response.Response, html, expected.xmlAssertions())
+	if errors.Is(err, ixml.ErrAssertionFailed) {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	return err
+}
+
+// checkText checks the text response, which must be identical to
+// or match the regular expression of the expected response.
+func checkText(expected, response *APIResponse) error {
+	err := ijson.CompareStrings(expected.Response, response.Response, expected.CaseInsensitive)
+	if errors.Is(err, ijson.ErrJSONMismatched) {
+		return errors.Join(err, ErrResponseMismatched)
+	}
+	return err
+}
+
+// checkDuration returns ErrTooSlow if the server took longer than
+// the expected (or server's default) maximum duration to respond.
+func (c *Client) checkDuration(expected, response *APIResponse) error {
+	maxDuration := expected.MaxDuration
+	if maxDuration == "" {
+		maxDuration = c.config.MaxDuration
+	}
+	if maxDuration == "" {
+		return nil
+	}
+	limit, err := time.ParseDuration(maxDuration)
+	if err != nil {
+		return err
+	}
+	if response.duration > limit {
+		return fmt.Errorf("%w: took %s, wanted at most %s", ErrTooSlow, response.duration.Round(time.Millisecond), limit)
+	}
+	return nil
 }
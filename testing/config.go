@@ -10,19 +10,23 @@ import (
 
 // Config holds okapi's configuration.
 type Config struct {
-	Servers      string `clap:"--servers-file,-s,mandatory"`
-	Directory    string `clap:"trailing"`
-	Timeout      int    `clap:"--timeout"`
-	UserAgent    string `clap:"--user-agent"`
-	ContentType  string `clap:"--content-type"`
-	Accept       string `clap:"--accept"`
-	File         string `clap:"--file,-f"`
-	Test         string `clap:"--test,-t"`
-	Workers      int    `clap:"--workers"`
-	Verbose      bool   `clap:"--verbose,-v"`
-	Parallel     bool   `clap:"--parallel,-p"`
-	FileParallel bool   `clap:"--file-parallel"`
-	setupCapture map[string]any
+	Servers         string `clap:"--servers-file,-s,mandatory"`
+	Directory       string `clap:"trailing"`
+	Timeout         int    `clap:"--timeout"`
+	UserAgent       string `clap:"--user-agent"`
+	ContentType     string `clap:"--content-type"`
+	Accept          string `clap:"--accept"`
+	File            string `clap:"--file,-f"`
+	Test            string `clap:"--test,-t"`
+	Workers         int    `clap:"--workers"`
+	Verbose         bool   `clap:"--verbose,-v"`
+	Parallel        bool   `clap:"--parallel,-p"`
+	FileParallel    bool   `clap:"--file-parallel"`
+	Report          string `clap:"--report"`
+	JSON            bool   `clap:"--json"`
+	UpdateSnapshots bool   `clap:"--update-snapshots"`
+	setupCapture    map[string]any
+	events          *eventWriter
 }
 
 // LoadConfig returns okapi's configuration from the
@@ -44,5 +48,10 @@ func LoadConfig(args []string) (*Config, error) {
 	if cfg.File != "" && !strings.HasSuffix(cfg.File, ".test.json") {
 		cfg.File = fmt.Sprintf("%s.test.json", cfg.File)
 	}
+	if cfg.Report != "" {
+		if _, _, err := parseReport(cfg.Report); err != nil {
+			return nil, err
+		}
+	}
 	return &cfg, nil
 }
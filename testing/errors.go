@@ -9,7 +9,32 @@ var (
 	// ErrResponseMismatched is returned if the server returned a
 	// content that differs from expected during a test.
 	ErrResponseMismatched error = errors.New("response mismatched")
+	// ErrHeaderMismatched is returned if the server returned a
+	// header that differs from expected during a test. It is
+	// always returned along with ErrResponseMismatched.
+	ErrHeaderMismatched error = errors.New("header mismatched")
+	// ErrTooSlow is returned if the server took longer than
+	// the maximum duration to respond during a test. It is
+	// always returned along with ErrResponseMismatched.
+	ErrTooSlow error = errors.New("response too slow")
+	// ErrUnsupportedCheck is returned if an assertion, an absent
+	// path or a schema cannot apply to the format of the response
+	// (e.g. an XPath assertion on a JSON response) during a test.
+	// It is always returned along with ErrResponseMismatched.
+	ErrUnsupportedCheck error = errors.New("unsupported check")
+	// ErrBodyMismatched is returned if the hash, the size or
+	// the content of the body returned by the server differs
+	// from expected during a test. It is always returned along
+	// with ErrResponseMismatched.
+	ErrBodyMismatched error = errors.New("body mismatched")
 	// ErrInvalidServerConfiguration is returned if the server
 	// configuration is not valid.
 	ErrInvalidServerConfiguration error = errors.New("invalid server configuration")
+	// ErrTestsFailed is returned by Run if at least one test
+	// failed or could not run.
+	ErrTestsFailed error = errors.New("tests failed")
+	// ErrTestsErrored is returned by Run if at least one test
+	// could not run (unreachable server, unreadable file, etc.).
+	// It is always returned along with ErrTestsFailed.
+	ErrTestsErrored error = errors.New("tests could not run")
 )
@@ -0,0 +1,175 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fred1268/okapi/testing/internal/log"
+)
+
+// Actions reported by events in JSON mode.
+const (
+	ActionRun    = "run"
+	ActionPass   = "pass"
+	ActionFail   = "fail"
+	ActionSkip   = "skip"
+	ActionOutput = "output"
+)
+
+// EventRequest represents the HTTP request sent during a test.
+type EventRequest struct {
+	// Method represents the HTTP Method.
+	Method string
+	// URL represents the full URL of the request.
+	URL string
+	// Headers represents the headers sent with the request. The
+	// values of the headers carrying credentials are redacted.
+	Headers map[string]string `json:",omitempty"`
+	// Payload represents the payload sent with the request, or
+	// a description of it for forms and multipart requests.
+	Payload string `json:",omitempty"`
+}
+
+// Event represents something which happened while running the
+// tests. In JSON mode, okapi emits each event as a JSON object
+// on its own line, pretty much like `go test -json` does.
+type Event struct {
+	// Time represents the time at which the event occurred.
+	Time time.Time
+	// Action represents what happened (run, pass, fail, skip
+	// or output).
+	Action string
+	// File represents the test file, if any.
+	File string `json:",omitempty"`
+	// Test represents the test name, if any.
+	Test string `json:",omitempty"`
+	// Elapsed represents the time taken, in seconds, by the
+	// test, the test file or the whole run.
+	Elapsed float64 `json:",omitempty"`
+	// StatusCode represents the HTTP Status Code returned by
+	// the server.
+	StatusCode int `json:",omitempty"`
+	// Request represents the HTTP request sent to the server.
+	Request *EventRequest `json:",omitempty"`
+	// Response represents the payload returned by the server.
+	Response string `json:",omitempty"`
+	// Error represents the reason why the test failed.
+	Error string `json:",omitempty"`
+	// Output represents a line of okapi's human readable output.
+	Output string `json:",omitempty"`
+}
+
+type eventWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func newEventWriter(w io.Writer) *eventWriter {
+	return &eventWriter{encoder: json.NewEncoder(w)}
+}
+
+func (e *eventWriter) emit(event *Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	event.Time = time.Now()
+	if err := e.encoder.Encode(event); err != nil {
+		log.Printf("Cannot emit event: %v\n", err)
+	}
+}
+
+// credentialHeaders represents the headers which always carry
+// credentials, whatever their source.
+var credentialHeaders = map[string]bool{"Authorization": true, "Proxy-Authorization": true, "Cookie": true}
+
+// isCredentialHeader returns true if the header is likely to carry
+// credentials (API keys, tokens, etc.) based on its name.
+func isCredentialHeader(key string) bool {
+	if credentialHeaders[key] {
+		return true
+	}
+	key = strings.ToLower(key)
+	for _, word := range []string{"token", "secret", "password", "api-key", "apikey"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// newEventRequest returns the request sent during a test. Since
+// events can be forwarded anywhere, the values of the headers
+// carrying credentials are redacted.
+func newEventRequest(req *http.Request, payload string, credentials map[string]bool) *EventRequest {
+	if req == nil {
+		return nil
+	}
+	request := &EventRequest{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Payload: payload,
+	}
+	if len(req.Header) != 0 {
+		request.Headers = make(map[string]string)
+		for key, values := range req.Header {
+			if credentials[key] || isCredentialHeader(key) {
+				request.Headers[key] = "(redacted)"
+				continue
+			}
+			request.Headers[key] = strings.Join(values, ", ")
+		}
+	}
+	return request
+}
+
+func newTestEvent(result *TestResult, payload string) *Event {
+	event := &Event{
+		File:    result.File,
+		Test:    result.Name,
+		Elapsed: result.Duration.Seconds(),
+	}
+	switch result.Status {
+	case StatusPass:
+		event.Action = ActionPass
+	case StatusSkip:
+		event.Action = ActionSkip
+	default:
+		event.Action = ActionFail
+	}
+	if result.Response != nil {
+		event.StatusCode = result.Response.StatusCode
+		event.Response = printable(result.Response.Response)
+		event.Request = newEventRequest(result.Response.request, payload, result.Response.credentials)
+	}
+	if result.Err != nil {
+		event.Error = result.Err.Error()
+	}
+	return event
+}
+
+// enableEvents makes okapi emit events on the standard output
+// while its human readable logs go to the standard error.
+func (c *Config) enableEvents() {
+	c.events = newEventWriter(os.Stdout)
+	log.SetOutput(os.Stderr)
+	log.DisableColors()
+}
+
+func (c *Config) emit(event *Event) {
+	if c.events != nil {
+		c.events.emit(event)
+	}
+}
+
+func (c *Config) printf(file, format string, args ...any) {
+	if c.events == nil {
+		log.Printf(format, args...)
+		return
+	}
+	c.events.emit(&Event{Action: ActionOutput, File: file, Output: fmt.Sprintf(format, args...)})
+}
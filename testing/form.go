@@ -0,0 +1,152 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	ijson "github.com/fred1268/okapi/testing/internal/json"
+	tos "github.com/fred1268/okapi/testing/internal/os"
+)
+
+// MultipartFile represents a file sent in a multipart/form-data
+// request.
+type MultipartFile struct {
+	// Name represents the name of the form field.
+	Name string
+	// File represents the path of the file, relative
+	// to the test directory.
+	File string
+	// ContentType represents the content type of the part.
+	// By default, it is deduced from the file extension.
+	ContentType string
+	// Filename represents the file name sent to the server.
+	// By default, it is the base name of File.
+	Filename string
+	content  []byte
+}
+
+// Multipart represents a multipart/form-data request body.
+type Multipart struct {
+	// Fields represents the (non file) form fields.
+	Fields map[string]string
+	// Files represents the files to upload.
+	Files []*MultipartFile
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func (m *Multipart) validate() error {
+	for _, file := range m.Files {
+		if file.Name == "" || file.File == "" {
+			return fmt.Errorf("empty multipart file name or file")
+		}
+	}
+	return nil
+}
+
+// readFiles reads the content of the files to upload.
+func (m *Multipart) readFiles(directory string) error {
+	for _, file := range m.Files {
+		content, err := os.ReadFile(path.Join(directory, file.File))
+		if err != nil {
+			return fmt.Errorf("cannot read multipart file '%s': %w", file.File, err)
+		}
+		file.content = content
+	}
+	return nil
+}
+
+func (m *Multipart) encode() (io.Reader, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, key := range ijson.SortedKeys(m.Fields) {
+		if err := writer.WriteField(key, m.Fields[key]); err != nil {
+			return nil, "", err
+		}
+	}
+	for _, file := range m.Files {
+		filename := file.Filename
+		if filename == "" {
+			filename = filepath.Base(file.File)
+		}
+		contentType := file.ContentType
+		if contentType == "" {
+			if contentType = mime.TypeByExtension(filepath.Ext(filename)); contentType == "" {
+				contentType = "application/octet-stream"
+			}
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(file.Name), quoteEscaper.Replace(filename)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err = part.Write(file.content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return &body, writer.FormDataContentType(), nil
+}
+
+// substituteFormVariables substitutes the captured variables
+// in the form and multipart fields.
+func (a *APIRequest) substituteFormVariables(captures map[string]any) {
+	for key, value := range a.Form {
+		a.Form[key] = tos.SubstituteCapturedVariable(value, captures)
+	}
+	if a.Multipart != nil {
+		for key, value := range a.Multipart.Fields {
+			a.Multipart.Fields[key] = tos.SubstituteCapturedVariable(value, captures)
+		}
+	}
+}
+
+func (a *APIRequest) formValues() url.Values {
+	values := make(url.Values)
+	for key, value := range a.Form {
+		values.Set(key, value)
+	}
+	return values
+}
+
+// body returns the body of the request along with its content
+// type, which is empty for raw payloads.
+func (a *APIRequest) body() (io.Reader, string, error) {
+	switch {
+	case a.Form != nil:
+		return strings.NewReader(a.formValues().Encode()), "application/x-www-form-urlencoded", nil
+	case a.Multipart != nil:
+		return a.Multipart.encode()
+	}
+	return strings.NewReader(a.Payload), "", nil
+}
+
+// describePayload returns a description of the payload of the
+// request for debugging purposes.
+func (a *APIRequest) describePayload() string {
+	switch {
+	case a.Form != nil:
+		return a.formValues().Encode()
+	case a.Multipart != nil:
+		var parts []string
+		for _, file := range a.Multipart.Files {
+			parts = append(parts, fmt.Sprintf("%s=@%s (%d bytes)", file.Name, file.File, len(file.content)))
+		}
+		return fmt.Sprintf("multipart (%d field(s)) %s", len(a.Multipart.Fields), strings.Join(parts, ", "))
+	}
+	return a.Payload
+}
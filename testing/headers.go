@@ -0,0 +1,98 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Sources of the request headers, from the lowest to the
+// highest priority.
+const (
+	sourceOkapi  = "okapi"
+	sourceServer = "server"
+	sourceAuth   = "auth"
+	sourceFile   = "file"
+	sourceTest   = "test"
+)
+
+type requestHeader struct {
+	value  string
+	source string
+}
+
+type headerLayer struct {
+	source  string
+	headers map[string]string
+}
+
+// requestHeaders returns the headers of the request, indexed by
+// their canonical key. Each layer overrides the previous ones,
+// and an empty value removes the header.
+func (c *Client) requestHeaders(apiRequest *APIRequest, contentType string) (map[string]*requestHeader,
+	map[string]string) {
+	defaults := map[string]string{"X-okapi-testname": apiRequest.Name}
+	server := make(map[string]string, len(c.config.Headers)+3)
+	// the user agent, accept and content type come either from
+	// the command line or from the server
+	configured := map[string]string{
+		"User-Agent": c.config.UserAgent,
+		"Accept":     c.config.Accept,
+	}
+	if apiRequest.Payload != "" {
+		configured["Content-Type"] = c.config.ContentType
+	}
+	for key, value := range configured {
+		if c.config.defaults[key] {
+			defaults[key] = value
+		} else {
+			server[key] = value
+		}
+	}
+	for key, value := range c.config.Headers {
+		server[http.CanonicalHeaderKey(key)] = value
+	}
+	test := make(map[string]string, len(apiRequest.Headers)+2)
+	for key, value := range apiRequest.Headers {
+		test[http.CanonicalHeaderKey(key)] = value
+	}
+	if apiRequest.Accept != "" {
+		test["Accept"] = apiRequest.Accept
+	}
+	// the content type of forms must match their encoding
+	if contentType != "" {
+		test["Content-Type"] = contentType
+	} else if apiRequest.ContentType != "" && apiRequest.Payload != "" {
+		test["Content-Type"] = apiRequest.ContentType
+	}
+	auth := make(map[string]string)
+	if c.config.Auth != nil && c.config.Auth.APIKey != nil {
+		auth[c.config.Auth.APIKey.Header] = c.config.Auth.APIKey.APIKey
+	}
+	if c.jwt != "" {
+		auth["Authorization"] = fmt.Sprintf("Bearer %s", c.jwt)
+	}
+	layers := []headerLayer{
+		{source: sourceOkapi, headers: defaults},
+		{source: sourceServer, headers: server},
+		{source: sourceAuth, headers: auth},
+		{source: sourceFile, headers: apiRequest.fileHeaders},
+		{source: sourceTest, headers: test},
+	}
+	headers := make(map[string]*requestHeader)
+	removed := make(map[string]string)
+	for _, layer := range layers {
+		for key, value := range layer.headers {
+			key = http.CanonicalHeaderKey(key)
+			if value == "" {
+				if _, found := headers[key]; found {
+					delete(headers, key)
+					removed[key] = layer.source
+				}
+				continue
+			}
+			headers[key] = &requestHeader{value: value, source: layer.source}
+			delete(removed, key)
+		}
+	}
+	return headers, removed
+}
@@ -0,0 +1,133 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	ijson "github.com/fred1268/okapi/testing/internal/json"
+)
+
+func describeHeaders(headers map[string]*requestHeader, removed map[string]string) string {
+	lines := make([]string, 0, len(headers)+len(removed))
+	for _, key := range ijson.SortedKeys(headers) {
+		lines = append(lines, fmt.Sprintf("%s: %s (%s)", key, headers[key].value, headers[key].source))
+	}
+	for _, key := range ijson.SortedKeys(removed) {
+		lines = append(lines, fmt.Sprintf("%s: removed (%s)", key, removed[key]))
+	}
+	return strings.Join(lines, ", ")
+}
+
+func TestRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *ServerConfig
+		jwt         string
+		request     *APIRequest
+		contentType string
+		wanted      string
+	}{
+		{
+			name: "command line defaults",
+			config: &ServerConfig{UserAgent: "okapi", Accept: "application/json",
+				defaults: map[string]bool{"User-Agent": true, "Accept": true}},
+			request: &APIRequest{Name: "test"},
+			wanted:  "Accept: application/json (okapi), User-Agent: okapi (okapi), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:    "server settings",
+			config:  &ServerConfig{UserAgent: "agent", Accept: "application/json", defaults: map[string]bool{"Accept": true}},
+			request: &APIRequest{Name: "test"},
+			wanted:  "Accept: application/json (okapi), User-Agent: agent (server), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:    "content type without payload",
+			config:  &ServerConfig{ContentType: "application/json"},
+			request: &APIRequest{Name: "test"},
+			wanted:  "X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:    "content type with payload",
+			config:  &ServerConfig{ContentType: "application/json"},
+			request: &APIRequest{Name: "test", Payload: "{}"},
+			wanted:  "Content-Type: application/json (server), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:    "server headers override okapi",
+			config:  &ServerConfig{Headers: map[string]string{"x-okapi-testname": "server"}},
+			request: &APIRequest{Name: "test"},
+			wanted:  "X-Okapi-Testname: server (server)",
+		},
+		{
+			name:    "auth overrides server",
+			config:  &ServerConfig{Headers: map[string]string{"Authorization": "Basic abc"}},
+			jwt:     "token",
+			request: &APIRequest{Name: "test"},
+			wanted:  "Authorization: Bearer token (auth), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name: "api key",
+			config: &ServerConfig{Headers: map[string]string{"X-Api-Key": "server"},
+				Auth: &Authentication{APIKey: &AuthenticationAPIKey{Header: "x-api-key", APIKey: "key"}}},
+			request: &APIRequest{Name: "test"},
+			wanted:  "X-Api-Key: key (auth), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:    "file overrides auth",
+			config:  &ServerConfig{},
+			jwt:     "token",
+			request: &APIRequest{Name: "test", fileHeaders: map[string]string{"authorization": "Basic abc"}},
+			wanted:  "Authorization: Basic abc (file), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:   "test overrides file",
+			config: &ServerConfig{},
+			request: &APIRequest{Name: "test", fileHeaders: map[string]string{"X-Tenant": "file"},
+				Headers: map[string]string{"x-tenant": "test"}},
+			wanted: "X-Okapi-Testname: test (okapi), X-Tenant: test (test)",
+		},
+		{
+			name:    "test accept",
+			config:  &ServerConfig{Accept: "application/json"},
+			request: &APIRequest{Name: "test", Accept: "text/html"},
+			wanted:  "Accept: text/html (test), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:   "form content type",
+			config: &ServerConfig{ContentType: "application/json"},
+			request: &APIRequest{Name: "test", ContentType: "text/plain",
+				Headers: map[string]string{"Content-Type": "text/plain"}},
+			contentType: "application/x-www-form-urlencoded",
+			wanted:      "Content-Type: application/x-www-form-urlencoded (test), X-Okapi-Testname: test (okapi)",
+		},
+		{
+			name:    "removed by the test",
+			config:  &ServerConfig{UserAgent: "agent", Headers: map[string]string{"X-Tenant": "server"}},
+			request: &APIRequest{Name: "test", Headers: map[string]string{"user-agent": "", "X-Tenant": ""}},
+			wanted:  "X-Okapi-Testname: test (okapi), User-Agent: removed (test), X-Tenant: removed (test)",
+		},
+		{
+			name:   "removed then set again",
+			config: &ServerConfig{Headers: map[string]string{"X-Tenant": "server"}},
+			request: &APIRequest{Name: "test", fileHeaders: map[string]string{"X-Tenant": ""},
+				Headers: map[string]string{"X-Tenant": "test"}},
+			wanted: "X-Okapi-Testname: test (okapi), X-Tenant: test (test)",
+		},
+		{
+			name:    "removing a missing header",
+			config:  &ServerConfig{},
+			request: &APIRequest{Name: "test", Headers: map[string]string{"X-Tenant": ""}},
+			wanted:  "X-Okapi-Testname: test (okapi)",
+		},
+	}
+	for _, tt := range tests {
+		client, request, contentType, wanted := &Client{config: tt.config, jwt: tt.jwt}, tt.request, tt.contentType, tt.wanted
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := describeHeaders(client.requestHeaders(request, contentType)); got != wanted {
+				t.Errorf("wanted: '%s', got '%s'", wanted, got)
+			}
+		})
+	}
+}
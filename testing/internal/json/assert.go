@@ -0,0 +1,255 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrAssertionFailed error = errors.New("assertion failed")
+
+// Assertion represents a check on the value(s) found at Path.
+type Assertion struct {
+	Path     string
+	Operator string
+	Value    any
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func order(wanted, got any) (int, error) {
+	if w, ok := toRat(wanted); ok {
+		if g, ok := toRat(got); ok {
+			return g.Cmp(w), nil
+		}
+	}
+	if w, ok := wanted.(string); ok {
+		if g, ok := got.(string); ok {
+			return strings.Compare(g, w), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", typeOf(got), typeOf(wanted))
+}
+
+func contains(wanted, got any) bool {
+	switch g := got.(type) {
+	case string:
+		if w, ok := wanted.(string); ok {
+			return strings.Contains(g, w)
+		}
+	case []any:
+		for _, element := range g {
+			if equalValues(wanted, element) {
+				return true
+			}
+		}
+	case map[string]any:
+		if w, ok := wanted.(string); ok {
+			_, found := g[w]
+			return found
+		}
+	}
+	return false
+}
+
+func check(operator string, wanted, got any) (bool, error) {
+	switch operator {
+	case "==":
+		return equalValues(wanted, got), nil
+	case "!=":
+		return !equalValues(wanted, got), nil
+	case "<", "<=", ">", ">=":
+		cmp, err := order(wanted, got)
+		if err != nil {
+			return false, err
+		}
+		switch operator {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	case "contains":
+		return contains(wanted, got), nil
+	case "matches":
+		pattern, ok := wanted.(string)
+		if !ok {
+			return false, fmt.Errorf("matches requires a string regular expression")
+		}
+		s, ok := got.(string)
+		if !ok {
+			content, err := json.Marshal(got)
+			if err != nil {
+				return false, err
+			}
+			s = string(content)
+		}
+		return regexp.MatchString(pattern, s)
+	case "type":
+		return wanted == typeOf(got), nil
+	}
+	return false, fmt.Errorf("unknown operator '%s'", operator)
+}
+
+var operators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"contains": true, "matches": true, "exists": true, "notExists": true, "type": true,
+}
+
+// Validate returns an error if the path or the operator of the
+// assertion is invalid.
+func (a *Assertion) Validate() error {
+	if _, err := parsePath(a.Path); err != nil {
+		return err
+	}
+	if !operators[a.Operator] {
+		return fmt.Errorf("unknown operator '%s'", a.Operator)
+	}
+	switch a.Operator {
+	case "<", "<=", ">", ">=":
+		if _, ok := a.Value.(string); !ok && !IsNumber(a.Value) {
+			return fmt.Errorf("%s requires a number or a string", a.Operator)
+		}
+	case "matches":
+		pattern, ok := a.Value.(string)
+		if !ok {
+			return fmt.Errorf("matches requires a string regular expression")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+	case "type":
+		switch a.Value {
+		case "null", "boolean", "number", "string", "array", "object":
+		default:
+			return fmt.Errorf("type requires one of null, boolean, number, string, array or object")
+		}
+	}
+	return nil
+}
+
+func (a *Assertion) check(document any) error {
+	values, err := Query(document, a.Path)
+	if err != nil {
+		return err
+	}
+	switch a.Operator {
+	case "exists":
+		if len(values) == 0 {
+			return fmt.Errorf("%w: %s does not exist", ErrAssertionFailed, a.Path)
+		}
+		return nil
+	case "notExists":
+		if len(values) != 0 {
+			return fmt.Errorf("%w: %s exists", ErrAssertionFailed, a.Path)
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%w: %s does not exist", ErrAssertionFailed, a.Path)
+	}
+	for _, value := range values {
+		// the assertion has been validated, so errors come from
+		// the value (e.g. comparing a string with a number)
+		ok, err := check(a.Operator, a.Value, value)
+		if err != nil {
+			return fmt.Errorf("%w: %s %s %s (got %s): %v", ErrAssertionFailed, a.Path, a.Operator,
+				display(a.Value), display(value), err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s %s %s (got %s)", ErrAssertionFailed, a.Path, a.Operator,
+				display(a.Value), display(value))
+		}
+	}
+	return nil
+}
+
+func display(value any) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(content)
+}
+
+// CheckAssertions runs all the assertions against the got JSON
+// document and returns the errors of the failing ones.
+func CheckAssertions(got string, assertions []*Assertion) error {
+	if len(assertions) == 0 {
+		return nil
+	}
+	document, err := decode(got)
+	if err != nil {
+		return fmt.Errorf("%w: response is not valid json: %v", ErrAssertionFailed, err)
+	}
+	var errs []error
+	for _, assertion := range assertions {
+		if err := assertion.check(document); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckAbsent returns an error for each of the JSONPath-like
+// paths matching at least one value of the got JSON document.
+// An empty response does not contain anything.
+func CheckAbsent(got string, paths []string) error {
+	if len(paths) == 0 || strings.TrimSpace(got) == "" {
+		return nil
+	}
+	document, err := decode(got)
+	if err != nil {
+		return fmt.Errorf("%w: response is not valid json: %v", ErrAssertionFailed, err)
+	}
+	var errs []error
+	for _, path := range paths {
+		values, err := Query(document, path)
+		if err != nil {
+			return err
+		}
+		if len(values) != 0 {
+			errs = append(errs, fmt.Errorf("%w: %s must be absent (got %s)", ErrAssertionFailed, path,
+				Truncate(display(values[0]))))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckNotContains returns an error for each of the regular
+// expressions matching the got response, JSON or not.
+func CheckNotContains(got string, patterns []string) error {
+	var errs []error
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		if loc := re.FindStringIndex(got); loc != nil {
+			errs = append(errs, fmt.Errorf("%w: response must not contain '%s' (got '%s')", ErrAssertionFailed,
+				pattern, Truncate(got[loc[0]:loc[1]])))
+		}
+	}
+	return errors.Join(errs...)
+}
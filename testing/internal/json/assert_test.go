@@ -0,0 +1,238 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCheckAssertions(t *testing.T) {
+	document := "{\"id\":12345678901234567,\"data\":{\"items\":[{\"price\":10,\"name\":\"first\"},{\"price\":20,\"name\":\"second\"}]}," +
+		"\"status\":\"active\",\"user\":{\"email\":\"a@b.c\"}}"
+	tests := []struct {
+		name      string
+		assertion *Assertion
+		result    error
+	}{
+		{
+			name:      "equal",
+			assertion: &Assertion{Path: "$.data.items[0].price", Operator: "==", Value: 10.0},
+			result:    nil,
+		},
+		{
+			name:      "not equal",
+			assertion: &Assertion{Path: "$.data.items[1].price", Operator: "==", Value: 10.0},
+			result:    ErrAssertionFailed,
+		},
+		{
+			name:      "negative index",
+			assertion: &Assertion{Path: "$.data.items[-1].name", Operator: "==", Value: "second"},
+			result:    nil,
+		},
+		{
+			name:      "length",
+			assertion: &Assertion{Path: "$.data.items.length()", Operator: ">=", Value: 2.0},
+			result:    nil,
+		},
+		{
+			name:      "wildcard",
+			assertion: &Assertion{Path: "$.data.items[*].price", Operator: ">", Value: 5.0},
+			result:    nil,
+		},
+		{
+			name:      "wildcard failing",
+			assertion: &Assertion{Path: "$.data.items[*].price", Operator: "<", Value: 15.0},
+			result:    ErrAssertionFailed,
+		},
+		{
+			name:      "contains",
+			assertion: &Assertion{Path: "$.status", Operator: "contains", Value: "act"},
+			result:    nil,
+		},
+		{
+			name:      "matches",
+			assertion: &Assertion{Path: "$['user']['email']", Operator: "matches", Value: "^[a-z]+@"},
+			result:    nil,
+		},
+		{
+			name:      "exists",
+			assertion: &Assertion{Path: "$..email", Operator: "exists"},
+			result:    nil,
+		},
+		{
+			name:      "not exists",
+			assertion: &Assertion{Path: "$.password", Operator: "notExists"},
+			result:    nil,
+		},
+		{
+			name:      "missing",
+			assertion: &Assertion{Path: "$.password", Operator: "==", Value: "x"},
+			result:    ErrAssertionFailed,
+		},
+		{
+			name:      "large integer",
+			assertion: &Assertion{Path: "$.id", Operator: "==", Value: json.Number("12345678901234567")},
+			result:    nil,
+		},
+		{
+			name:      "type",
+			assertion: &Assertion{Path: "$.data.items", Operator: "type", Value: "array"},
+			result:    nil,
+		},
+		{
+			name:      "type mismatch",
+			assertion: &Assertion{Path: "$.status", Operator: "<", Value: json.Number("10")},
+			result:    ErrAssertionFailed,
+		},
+	}
+	for _, tt := range tests {
+		assertion := tt.assertion
+		res := tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := assertion.Validate(); err != nil {
+				t.Fatalf("invalid assertion: %v", err)
+			}
+			err := CheckAssertions(document, []*Assertion{assertion})
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
+
+func TestValidateAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion *Assertion
+		valid     bool
+	}{
+		{
+			name:      "valid",
+			assertion: &Assertion{Path: "$.name", Operator: "matches", Value: "^[a-z]+$"},
+			valid:     true,
+		},
+		{
+			name:      "invalid path",
+			assertion: &Assertion{Path: "name", Operator: "exists"},
+		},
+		{
+			name:      "unknown operator",
+			assertion: &Assertion{Path: "$.name", Operator: "~="},
+		},
+		{
+			name:      "invalid regular expression",
+			assertion: &Assertion{Path: "$.name", Operator: "matches", Value: "[a-z"},
+		},
+		{
+			name:      "non string regular expression",
+			assertion: &Assertion{Path: "$.name", Operator: "matches", Value: json.Number("1")},
+		},
+		{
+			name:      "non comparable value",
+			assertion: &Assertion{Path: "$.price", Operator: "<", Value: true},
+		},
+		{
+			name:      "unknown type",
+			assertion: &Assertion{Path: "$.price", Operator: "type", Value: "integer"},
+		},
+	}
+	for _, tt := range tests {
+		assertion, valid := tt.assertion, tt.valid
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := assertion.Validate(); (err == nil) != valid {
+				t.Errorf("wanted: valid %t, got '%v'", valid, err)
+			}
+		})
+	}
+}
+
+func TestCheckAbsent(t *testing.T) {
+	document := "{\"users\":[{\"id\":1,\"name\":\"first\"},{\"id\":2,\"email\":\"a@b.c\"}],\"error\":null}"
+	tests := []struct {
+		name     string
+		document string
+		paths    []string
+		result   error
+	}{
+		{
+			name:     "absent",
+			document: document,
+			paths:    []string{"$.users[*].password", "$.stack"},
+			result:   nil,
+		},
+		{
+			name:     "present",
+			document: document,
+			paths:    []string{"$.users[*].email"},
+			result:   ErrAssertionFailed,
+		},
+		{
+			name:     "recursive",
+			document: document,
+			paths:    []string{"$..email"},
+			result:   ErrAssertionFailed,
+		},
+		{
+			name:     "null",
+			document: document,
+			paths:    []string{"$.error"},
+			result:   ErrAssertionFailed,
+		},
+		{
+			name:     "empty response",
+			document: "",
+			paths:    []string{"$..email"},
+			result:   nil,
+		},
+	}
+	for _, tt := range tests {
+		document, paths, res := tt.document, tt.paths, tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckAbsent(document, paths)
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
+
+func TestCheckNotContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patterns []string
+		result   error
+	}{
+		{
+			name:     "not contained",
+			document: "{\"error\":\"not found\"}",
+			patterns: []string{"(?i)exception", "\\.go:\\d+"},
+			result:   nil,
+		},
+		{
+			name:     "stack trace",
+			document: "{\"error\":\"panic\",\"trace\":\"main.go:42\"}",
+			patterns: []string{"(?i)exception", "\\.go:\\d+"},
+			result:   ErrAssertionFailed,
+		},
+		{
+			name:     "not json",
+			document: "<html>java.lang.NullPointerException</html>",
+			patterns: []string{"(?i)exception"},
+			result:   ErrAssertionFailed,
+		},
+	}
+	for _, tt := range tests {
+		document, patterns, res := tt.document, tt.patterns, tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckNotContains(document, patterns)
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package json
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reasons of a Mismatch.
+const (
+	ReasonMissingKey      = "missing key"
+	ReasonUnexpectedKey   = "unexpected key"
+	ReasonTypeMismatch    = "type mismatch"
+	ReasonValueMismatch   = "value mismatch"
+	ReasonLengthMismatch  = "length mismatch"
+	ReasonElementNotFound = "array element not found"
+	ReasonMatcherMismatch = "matcher mismatch"
+	ReasonInvalidMatcher  = "invalid matcher"
+	ReasonInvalidDocument = "invalid document"
+)
+
+const maxDisplayLength = 80
+
+// Mismatch represents a difference between the wanted
+// and the got JSON documents.
+type Mismatch struct {
+	// Pointer represents the JSON pointer of the value
+	// in the got document.
+	Pointer string
+	// Reason represents the kind of difference.
+	Reason string
+	// Wanted represents the wanted value, as JSON.
+	Wanted string
+	// Got represents the got value, as JSON.
+	Got string
+}
+
+// MismatchError is returned by CompareJSONStrings when the
+// documents differ. It matches ErrJSONMismatched.
+type MismatchError struct {
+	Mismatches []*Mismatch
+}
+
+func (e *MismatchError) Error() string {
+	lines := []string{fmt.Sprintf("%s (%d difference(s))", ErrJSONMismatched, len(e.Mismatches))}
+	for _, mismatch := range e.Mismatches {
+		lines = append(lines, mismatch.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *MismatchError) Is(target error) bool {
+	return target == ErrJSONMismatched
+}
+
+func (m *Mismatch) String() string {
+	return fmt.Sprintf("%s: %s, wanted %s, got %s", m.Location(), m.Reason, m.Wanted, m.Got)
+}
+
+// Location returns the pointer of the mismatch, or / for
+// the root of the document.
+func (m *Mismatch) Location() string {
+	if m.Pointer == "" {
+		return "/"
+	}
+	return m.Pointer
+}
+
+func pointer(location []segment) string {
+	var sb strings.Builder
+	for _, seg := range location {
+		sb.WriteString("/")
+		if seg.kind == segmentIndex {
+			sb.WriteString(fmt.Sprintf("%d", seg.index))
+		} else {
+			sb.WriteString(pointerEscape(seg.key))
+		}
+	}
+	return sb.String()
+}
+
+// Truncate returns the value truncated to a length which
+// can be displayed in a report.
+func Truncate(value string) string {
+	if runes := []rune(value); len(runes) > maxDisplayLength {
+		return fmt.Sprintf("%s...", string(runes[:maxDisplayLength]))
+	}
+	return value
+}
+
+func newMismatch(location []segment, reason string, wanted, got any) *Mismatch {
+	mismatch := &Mismatch{Pointer: pointer(location), Reason: reason, Wanted: "(none)", Got: "(none)"}
+	if reason != ReasonUnexpectedKey {
+		mismatch.Wanted = Truncate(display(wanted))
+	}
+	if reason != ReasonMissingKey && reason != ReasonElementNotFound {
+		mismatch.Got = Truncate(display(got))
+	}
+	return mismatch
+}
@@ -3,107 +3,433 @@ package json
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 var ErrJSONMismatched error = errors.New("json mismatched")
 
-func compareStrings(wanted, got string) error {
-	matched, err := regexp.MatchString(wanted, got)
+// Array comparison modes.
+const (
+	// ArrayContains makes sure all the wanted elements are
+	// found in got, in any order (default).
+	ArrayContains = "contains"
+	// ArrayExact makes sure wanted and got have the same
+	// length and the same elements in the same order.
+	ArrayExact = "exact"
+	// ArrayOrdered makes sure all the wanted elements are
+	// found in got, in the same order.
+	ArrayOrdered = "ordered"
+	// ArraySet makes sure wanted and got have the same
+	// elements, in any order.
+	ArraySet = "set"
+)
+
+// Options represents how the JSON documents are compared.
+type Options struct {
+	// CaseInsensitive makes the comparison of strings (and
+	// object keys) case insensitive.
+	CaseInsensitive bool
+	// ArrayMode represents how arrays are compared (contains,
+	// exact, ordered or set). Default is contains.
+	ArrayMode string
+	// Strict makes objects mismatch if got contains fields
+	// which are not in wanted.
+	Strict bool
+	// Tolerance represents how much numbers can differ. By
+	// default, numbers (including large integers) must be
+	// strictly equal.
+	Tolerance *Tolerance
+	// Paths represents options overriding the above ones for
+	// specific JSONPath-like paths (and their children). The
+	// most specific path wins.
+	Paths map[string]*PathOptions
+}
+
+// PathOptions represents options applying to a specific path.
+// A nil field means the option is inherited.
+type PathOptions struct {
+	CaseInsensitive *bool
+	ArrayMode       string
+	Strict          *bool
+	Tolerance       *Tolerance
+}
+
+type settings struct {
+	caseInsensitive bool
+	arrayMode       string
+	strict          bool
+	tolerance       *Tolerance
+}
+
+type pathRule struct {
+	pattern []segment
+	options *PathOptions
+}
+
+type comparer struct {
+	defaults settings
+	paths    []*pathRule
+}
+
+func validateArrayMode(mode string) error {
+	switch mode {
+	case "", ArrayContains, ArrayExact, ArrayOrdered, ArraySet:
+		return nil
+	}
+	return fmt.Errorf("invalid array mode '%s'", mode)
+}
+
+func validateTolerance(tolerance *Tolerance) error {
+	if tolerance != nil && (tolerance.Absolute < 0 || tolerance.Relative < 0) {
+		return fmt.Errorf("invalid negative tolerance")
+	}
+	return nil
+}
+
+// Validate returns an error if one of the options or
+// paths is invalid.
+func (o *Options) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if err := validateArrayMode(o.ArrayMode); err != nil {
+		return err
+	}
+	if err := validateTolerance(o.Tolerance); err != nil {
+		return err
+	}
+	for path, options := range o.Paths {
+		if _, err := parsePath(path); err != nil {
+			return err
+		}
+		if options != nil {
+			if err := validateArrayMode(options.ArrayMode); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := validateTolerance(options.Tolerance); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func newComparer(options *Options) (*comparer, error) {
+	c := &comparer{defaults: settings{arrayMode: ArrayContains}}
+	if options == nil {
+		return c, nil
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	c.defaults.caseInsensitive = options.CaseInsensitive
+	c.defaults.strict = options.Strict
+	c.defaults.tolerance = options.Tolerance
+	if options.ArrayMode != "" {
+		c.defaults.arrayMode = options.ArrayMode
+	}
+	for path, pathOptions := range options.Paths {
+		pattern, err := parsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		c.paths = append(c.paths, &pathRule{pattern: pattern, options: pathOptions})
+	}
+	sort.SliceStable(c.paths, func(i, j int) bool {
+		return len(c.paths[i].pattern) < len(c.paths[j].pattern)
+	})
+	return c, nil
+}
+
+func (c *comparer) settings(location []segment) settings {
+	s := c.defaults
+	for _, path := range c.paths {
+		if path.options == nil || !matchPrefix(path.pattern, location) {
+			continue
+		}
+		if path.options.CaseInsensitive != nil {
+			s.caseInsensitive = *path.options.CaseInsensitive
+		}
+		if path.options.ArrayMode != "" {
+			s.arrayMode = path.options.ArrayMode
+		}
+		if path.options.Strict != nil {
+			s.strict = *path.options.Strict
+		}
+		if path.options.Tolerance != nil {
+			s.tolerance = path.options.Tolerance
+		}
+	}
+	return s
+}
+
+// SortedKeys returns the keys of the map, sorted.
+func SortedKeys[V any](obj map[string]V) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func child(location []segment, seg segment) []segment {
+	result := make([]segment, len(location), len(location)+1)
+	copy(result, location)
+	return append(result, seg)
+}
+
+func compareStrings(wanted, got string, caseInsensitive bool) error {
+	pattern := wanted
+	if caseInsensitive {
+		pattern = fmt.Sprintf("(?i)%s", wanted)
+	}
+	matched, err := regexp.MatchString(pattern, got)
 	if err != nil {
 		return err
 	}
 	if !matched {
-		return ErrJSONMismatched
+		return &MismatchError{Mismatches: []*Mismatch{newMismatch(nil, ReasonValueMismatch, wanted, got)}}
 	}
 	return nil
 }
 
-func compareSlices(src, dst []any) error {
-	found := 0
-	for _, value := range src {
-		for _, dstValue := range dst {
-			if reflect.TypeOf(value) != reflect.TypeOf(dstValue) {
-				continue
+// CompareStrings compares the wanted and got strings, which are
+// not considered as JSON documents: got must either be identical
+// to wanted, or match the wanted regular expression. It returns
+// a *MismatchError if it does not.
+func CompareStrings(wanted, got string, caseInsensitive bool) error {
+	if wanted == "" || got == wanted {
+		return nil
+	}
+	return compareStrings(wanted, got, caseInsensitive)
+}
+
+func (c *comparer) compareValues(value, dstValue any, location []segment) []*Mismatch {
+	if token, ok := value.(string); ok {
+		if match, ok := parseMatcher(token); ok {
+			matched, err := match(dstValue, c.settings(location).caseInsensitive)
+			if err != nil {
+				return []*Mismatch{newMismatch(location, ReasonInvalidMatcher, fmt.Sprintf("%s (%v)", token, err),
+					dstValue)}
 			}
-			switch value.(type) {
-			case nil:
-			case map[string]any:
-				if err := compareMaps(value.(map[string]any), dstValue.(map[string]any)); err != nil {
-					continue
-				}
-			case []any:
-				if err := compareSlices(value.([]any), dstValue.([]any)); err != nil {
-					continue
-				}
-			default:
-				if value != dstValue {
-					continue
-				}
+			if !matched {
+				return []*Mismatch{newMismatch(location, ReasonMatcherMismatch, value, dstValue)}
 			}
-			found++
-			break
+			return nil
 		}
+		if strings.HasPrefix(token, "$$") {
+			value = token[1:]
+		}
+	}
+	if reflect.TypeOf(value) != reflect.TypeOf(dstValue) {
+		return []*Mismatch{newMismatch(location, ReasonTypeMismatch, value, dstValue)}
 	}
-	if found != len(src) {
-		return ErrJSONMismatched
+	switch value := value.(type) {
+	case nil:
+	case map[string]any:
+		return c.compareMaps(value, dstValue.(map[string]any), location)
+	case []any:
+		return c.compareSlices(value, dstValue.([]any), location)
+	case string:
+		if value == dstValue {
+			return nil
+		}
+		if c.settings(location).caseInsensitive && strings.EqualFold(value, dstValue.(string)) {
+			return nil
+		}
+		return []*Mismatch{newMismatch(location, ReasonValueMismatch, value, dstValue)}
+	case json.Number:
+		if !compareNumbers(value, dstValue, c.settings(location).tolerance) {
+			return []*Mismatch{newMismatch(location, ReasonValueMismatch, value, dstValue)}
+		}
+	default:
+		if value != dstValue {
+			return []*Mismatch{newMismatch(location, ReasonValueMismatch, value, dstValue)}
+		}
 	}
 	return nil
 }
 
-func compareMaps(src, dst map[string]any) error {
-	for key, value := range src {
-		dstValue, found := dst[key]
+func (c *comparer) matchValues(value, dstValue any, location []segment, index int) bool {
+	return len(c.compareValues(value, dstValue, child(location, segment{kind: segmentIndex, index: index}))) == 0
+}
+
+func (c *comparer) compareSlices(src, dst []any, location []segment) []*Mismatch {
+	switch c.settings(location).arrayMode {
+	case ArrayExact:
+		return c.compareExactSlices(src, dst, location)
+	case ArrayOrdered:
+		return c.compareOrderedSlices(src, dst, location)
+	case ArraySet:
+		return c.compareSetSlices(src, dst, location)
+	}
+	var mismatches []*Mismatch
+	for _, value := range src {
+		found := false
+		for index, dstValue := range dst {
+			if c.matchValues(value, dstValue, location, index) {
+				found = true
+				break
+			}
+		}
 		if !found {
-			return ErrJSONMismatched
+			mismatches = append(mismatches, newMismatch(location, ReasonElementNotFound, value, nil))
+		}
+	}
+	return mismatches
+}
+
+func (c *comparer) compareExactSlices(src, dst []any, location []segment) []*Mismatch {
+	var mismatches []*Mismatch
+	if len(src) != len(dst) {
+		mismatches = append(mismatches, newMismatch(location, ReasonLengthMismatch, len(src), len(dst)))
+	}
+	for index, value := range src {
+		if index >= len(dst) {
+			break
 		}
-		if reflect.TypeOf(value) != reflect.TypeOf(dstValue) {
-			return ErrJSONMismatched
+		mismatches = append(mismatches,
+			c.compareValues(value, dst[index], child(location, segment{kind: segmentIndex, index: index}))...)
+	}
+	return mismatches
+}
+
+func (c *comparer) compareOrderedSlices(src, dst []any, location []segment) []*Mismatch {
+	var mismatches []*Mismatch
+	index := 0
+	for _, value := range src {
+		next := index
+		for ; next < len(dst); next++ {
+			if c.matchValues(value, dst[next], location, next) {
+				break
+			}
+		}
+		if next == len(dst) {
+			mismatches = append(mismatches, newMismatch(location, ReasonElementNotFound, value, nil))
+			continue
+		}
+		index = next + 1
+	}
+	return mismatches
+}
+
+// compareSetSlices pairs each wanted element with a distinct got
+// element (bipartite matching), since with partial objects an
+// element may match several others.
+func (c *comparer) compareSetSlices(src, dst []any, location []segment) []*Mismatch {
+	var mismatches []*Mismatch
+	if len(src) != len(dst) {
+		mismatches = append(mismatches, newMismatch(location, ReasonLengthMismatch, len(src), len(dst)))
+	}
+	matches := make([][]bool, len(src))
+	for i, value := range src {
+		matches[i] = make([]bool, len(dst))
+		for j, dstValue := range dst {
+			matches[i][j] = c.matchValues(value, dstValue, location, j)
 		}
-		switch value.(type) {
-		case nil:
-		case map[string]any:
-			if err := compareMaps(value.(map[string]any), dstValue.(map[string]any)); err != nil {
-				return err
+	}
+	owners := make([]int, len(dst))
+	for j := range owners {
+		owners[j] = -1
+	}
+	var assign func(i int, visited []bool) bool
+	assign = func(i int, visited []bool) bool {
+		for j := range dst {
+			if !matches[i][j] || visited[j] {
+				continue
 			}
-		case []any:
-			if err := compareSlices(value.([]any), dstValue.([]any)); err != nil {
-				return err
+			visited[j] = true
+			if owners[j] == -1 || assign(owners[j], visited) {
+				owners[j] = i
+				return true
 			}
-		default:
-			if value != dstValue {
-				return ErrJSONMismatched
+		}
+		return false
+	}
+	for i, value := range src {
+		if !assign(i, make([]bool, len(dst))) {
+			mismatches = append(mismatches, newMismatch(location, ReasonElementNotFound, value, nil))
+		}
+	}
+	return mismatches
+}
+
+func (c *comparer) lookup(dst map[string]any, key string, location []segment) (string, any, bool) {
+	if value, found := dst[key]; found {
+		return key, value, true
+	}
+	if c.settings(location).caseInsensitive {
+		for _, dstKey := range SortedKeys(dst) {
+			if strings.EqualFold(key, dstKey) {
+				return dstKey, dst[dstKey], true
 			}
 		}
 	}
-	return nil
+	return key, nil, false
+}
+
+func (c *comparer) compareMaps(src, dst map[string]any, location []segment) []*Mismatch {
+	var mismatches []*Mismatch
+	for _, key := range SortedKeys(src) {
+		dstKey, dstValue, found := c.lookup(dst, key, location)
+		if !found {
+			mismatches = append(mismatches,
+				newMismatch(child(location, segment{kind: segmentKey, key: key}), ReasonMissingKey, src[key], nil))
+			continue
+		}
+		mismatches = append(mismatches,
+			c.compareValues(src[key], dstValue, child(location, segment{kind: segmentKey, key: dstKey}))...)
+	}
+	if c.settings(location).strict {
+		for _, key := range SortedKeys(dst) {
+			if _, _, found := c.lookup(src, key, location); !found {
+				mismatches = append(mismatches,
+					newMismatch(child(location, segment{kind: segmentKey, key: key}), ReasonUnexpectedKey, nil, dst[key]))
+			}
+		}
+	}
+	return mismatches
 }
 
-func CompareJSONStrings(wanted, got string) error {
+// CompareJSONStrings compares the wanted and got strings.
+//
+// If both strings are JSON documents (objects, arrays or
+// scalars), they are compared structurally: objects in got
+// must contain (at least) all the fields of wanted, arrays
+// are compared according to the array mode. If only wanted
+// is a JSON document, the strings mismatch. Otherwise, wanted
+// is used as a regular expression which must match got.
+//
+// If the strings differ, a *MismatchError listing all the
+// differences is returned.
+func CompareJSONStrings(wanted, got string, options *Options) error {
+	c, err := newComparer(options)
+	if err != nil {
+		return err
+	}
 	// perfectly identical
 	if wanted == "" || got == wanted {
 		return nil
 	}
-	// one is not a json object, compare strings
-	if !strings.Contains(wanted, "{") || !strings.Contains(got, "{") {
-		return compareStrings(wanted, got)
-	}
-	// both are json, compare json
-	var g, w interface{}
-	err := json.Unmarshal([]byte(strings.ToLower(got)), &g)
+	// wanted is not a json document, compare strings
+	w, err := decode(wanted)
 	if err != nil {
-		return err
+		return compareStrings(wanted, got, c.defaults.caseInsensitive)
 	}
-	err = json.Unmarshal([]byte(strings.ToLower(wanted)), &w)
+	// wanted is json but got is not: never use wanted as a
+	// regular expression (e.g. [1,2,3] is a character class)
+	g, err := decode(got)
 	if err != nil {
-		return err
+		return &MismatchError{Mismatches: []*Mismatch{newMismatch(nil, ReasonInvalidDocument, w, got)}}
 	}
-	if wantedMap, ok := w.(map[string]any); ok {
-		if gotMap, ok := g.(map[string]any); ok {
-			return compareMaps(wantedMap, gotMap)
-		}
+	// both are json, compare json
+	if mismatches := c.compareValues(w, g, nil); len(mismatches) != 0 {
+		return &MismatchError{Mismatches: mismatches}
 	}
-	return ErrJSONMismatched
+	return nil
 }
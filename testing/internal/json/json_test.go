@@ -1,15 +1,19 @@
 package json
 
 import (
+	"errors"
 	"testing"
 )
 
 func TestCompareJSON(t *testing.T) {
+	enabled := true
+	disabled := false
 	tests := []struct {
-		name   string
-		src    string
-		dst    string
-		result error
+		name    string
+		src     string
+		dst     string
+		options *Options
+		result  error
 	}{
 		{
 			name:   "empty",
@@ -47,18 +51,340 @@ func TestCompareJSON(t *testing.T) {
 			dst:    "{\"id\":10,\"field1\":\"value1\",\"field2\":{\"field21\":\"value3\"}}",
 			result: ErrJSONMismatched,
 		},
+		{
+			name:   "case sensitive",
+			src:    "{\"status\":\"active\"}",
+			dst:    "{\"status\":\"ACTIVE\"}",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:    "case insensitive",
+			src:     "{\"status\":\"active\"}",
+			dst:     "{\"Status\":\"ACTIVE\"}",
+			options: &Options{CaseInsensitive: true},
+			result:  nil,
+		},
+		{
+			name:    "case insensitive field",
+			src:     "{\"status\":\"active\",\"token\":\"aBc\"}",
+			dst:     "{\"status\":\"ACTIVE\",\"token\":\"aBc\"}",
+			options: &Options{Paths: map[string]*PathOptions{"$.status": {CaseInsensitive: &enabled}}},
+			result:  nil,
+		},
+		{
+			name:    "case insensitive other field",
+			src:     "{\"status\":\"active\",\"token\":\"abc\"}",
+			dst:     "{\"status\":\"ACTIVE\",\"token\":\"aBc\"}",
+			options: &Options{Paths: map[string]*PathOptions{"$.status": {CaseInsensitive: &enabled}}},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:   "array contains",
+			src:    "{\"items\":[1]}",
+			dst:    "{\"items\":[3,2,1]}",
+			result: nil,
+		},
+		{
+			name:    "array exact",
+			src:     "{\"items\":[1,2,3]}",
+			dst:     "{\"items\":[1,2,3]}",
+			options: &Options{ArrayMode: ArrayExact},
+			result:  nil,
+		},
+		{
+			name:    "array exact wrong order",
+			src:     "{\"items\":[1,2,3]}",
+			dst:     "{\"items\":[3,2,1]}",
+			options: &Options{ArrayMode: ArrayExact},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:    "array ordered",
+			src:     "{\"items\":[1,3]}",
+			dst:     "{\"items\":[1,2,3]}",
+			options: &Options{ArrayMode: ArrayOrdered},
+			result:  nil,
+		},
+		{
+			name:    "array ordered wrong order",
+			src:     "{\"items\":[3,1]}",
+			dst:     "{\"items\":[1,2,3]}",
+			options: &Options{ArrayMode: ArrayOrdered},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:    "array set",
+			src:     "{\"items\":[{\"id\":1},{\"id\":1,\"v\":2}]}",
+			dst:     "{\"items\":[{\"id\":1,\"v\":2},{\"id\":1,\"v\":3}]}",
+			options: &Options{ArrayMode: ArraySet},
+			result:  nil,
+		},
+		{
+			name:    "array set duplicates",
+			src:     "{\"items\":[1,1,2]}",
+			dst:     "{\"items\":[1,2,2]}",
+			options: &Options{ArrayMode: ArraySet},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:    "array mode per path",
+			src:     "{\"items\":[1,2],\"tags\":[\"b\"]}",
+			dst:     "{\"items\":[1,2],\"tags\":[\"a\",\"b\"]}",
+			options: &Options{ArrayMode: ArrayExact, Paths: map[string]*PathOptions{"$.tags": {ArrayMode: ArrayContains}}},
+			result:  nil,
+		},
+		{
+			name:    "strict",
+			src:     "{\"id\":10,\"user\":{\"name\":\"john\"}}",
+			dst:     "{\"id\":10,\"user\":{\"name\":\"john\"}}",
+			options: &Options{Strict: true},
+			result:  nil,
+		},
+		{
+			name:    "strict extra field",
+			src:     "{\"id\":10,\"user\":{\"name\":\"john\"}}",
+			dst:     "{\"id\":10,\"user\":{\"name\":\"john\",\"password_hash\":\"x\"}}",
+			options: &Options{Strict: true},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:    "strict per path",
+			src:     "{\"id\":10,\"user\":{\"name\":\"john\"}}",
+			dst:     "{\"id\":10,\"extra\":1,\"user\":{\"name\":\"john\",\"password_hash\":\"x\"}}",
+			options: &Options{Paths: map[string]*PathOptions{"$.user": {Strict: &enabled}}},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:    "strict except path",
+			src:     "{\"id\":10,\"meta\":{}}",
+			dst:     "{\"id\":10,\"meta\":{\"took\":3}}",
+			options: &Options{Strict: true, Paths: map[string]*PathOptions{"$.meta": {Strict: &disabled}}},
+			result:  nil,
+		},
+		{
+			name:   "top level array",
+			src:    "[{\"id\":1}]",
+			dst:    "[{\"id\":2},{\"id\":1,\"name\":\"one\"}]",
+			result: nil,
+		},
+		{
+			name:   "top level array mismatch",
+			src:    "[{\"id\":3}]",
+			dst:    "[{\"id\":2},{\"id\":1}]",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:    "top level exact array",
+			src:     "[1,2]",
+			dst:     "[1,2,3]",
+			options: &Options{ArrayMode: ArrayExact},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:   "top level number",
+			src:    "42",
+			dst:    "42.0",
+			result: nil,
+		},
+		{
+			name:   "top level number mismatch",
+			src:    "1",
+			dst:    "{\"id\":1}",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "top level null",
+			src:    "null",
+			dst:    " null\n",
+			result: nil,
+		},
+		{
+			name:   "top level boolean mismatch",
+			src:    "true",
+			dst:    "false",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "regular expression",
+			src:    "\\s+[wW]eight",
+			dst:    "the  Weight",
+			result: nil,
+		},
+		{
+			name: "matchers",
+			src: "{\"id\":\"$uuid\",\"at\":\"$datetime\",\"ref\":\"$regex:^ord_[a-z0-9]+$\",\"total\":\"$gt:0\"," +
+				"\"items\":\"$len:2\",\"note\":\"$any\",\"count\":\"$type:integer\",\"price\":\"$type:number\"," +
+				"\"currency\":\"$$USD\"}",
+			dst: "{\"id\":\"3fa85f64-5717-4562-b3fc-2c963f66afa6\",\"at\":\"2023-06-01T10:00:00Z\",\"ref\":\"ord_a1\"," +
+				"\"total\":12.5,\"items\":[1,2],\"note\":null,\"count\":3,\"price\":9.99,\"currency\":\"$USD\"}",
+			result: nil,
+		},
+		{
+			name:   "matcher mismatch",
+			src:    "{\"total\":\"$gt:0\"}",
+			dst:    "{\"total\":0}",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "matcher missing key",
+			src:    "{\"note\":\"$any\"}",
+			dst:    "{}",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "matcher in array",
+			src:    "{\"items\":[{\"id\":\"$type:string\"}]}",
+			dst:    "{\"items\":[{\"id\":1},{\"id\":\"a\"}]}",
+			result: nil,
+		},
+		{
+			name:   "large integers",
+			src:    "{\"id\":12345678901234567}",
+			dst:    "{\"id\":12345678901234568}",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "float without tolerance",
+			src:    "{\"score\":0.3}",
+			dst:    "{\"score\":0.30000000000000004}",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:    "absolute tolerance",
+			src:     "{\"score\":0.3}",
+			dst:     "{\"score\":0.30000000000000004}",
+			options: &Options{Tolerance: &Tolerance{Absolute: 1e-9}},
+			result:  nil,
+		},
+		{
+			name:    "relative tolerance",
+			src:     "{\"amount\":100,\"id\":1}",
+			dst:     "{\"amount\":100.9,\"id\":2}",
+			options: &Options{Paths: map[string]*PathOptions{"$.amount": {Tolerance: &Tolerance{Relative: 0.01}}}},
+			result:  ErrJSONMismatched,
+		},
+		{
+			name:    "relative tolerance per path",
+			src:     "{\"amount\":100,\"id\":1}",
+			dst:     "{\"amount\":100.9,\"id\":1}",
+			options: &Options{Paths: map[string]*PathOptions{"$.amount": {Tolerance: &Tolerance{Relative: 0.01}}}},
+			result:  nil,
+		},
+		{
+			name:   "json array against text",
+			src:    "[1,2,3]",
+			dst:    "internal error 3",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "json object against text",
+			src:    "{\"id\":1}",
+			dst:    "<html>{\"id\":1}</html>",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:   "regular expression against text",
+			src:    "^internal error [0-9]+$",
+			dst:    "internal error 3",
+			result: nil,
+		},
 	}
 	for _, tt := range tests {
 		name := tt.name
 		src := tt.src
 		dst := tt.dst
+		options := tt.options
 		res := tt.result
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			err := CompareJSONStrings(src, dst)
-			if err != res {
+			err := CompareJSONStrings(src, dst, options)
+			if !errors.Is(err, res) || res == nil && err != nil {
 				t.Errorf("wanted: '%s', got '%s'", dst, src)
 			}
 		})
 	}
 }
+
+func TestCompareJSONDiff(t *testing.T) {
+	src := "{\"id\":10,\"name\":\"john\",\"age\":\"12\",\"tags\":[\"a\",\"z\"],\"address\":{\"city\":\"paris\"}}"
+	dst := "{\"id\":11,\"age\":12,\"tags\":[\"a\",\"b\"],\"address\":{\"city\":\"paris\",\"zip\":\"75001\"}}"
+	wanted := []string{
+		"/address/zip: unexpected key, wanted (none), got \"75001\"",
+		"/age: type mismatch, wanted \"12\", got 12",
+		"/id: value mismatch, wanted 10, got 11",
+		"/name: missing key, wanted \"john\", got (none)",
+		"/tags: array element not found, wanted \"z\", got (none)",
+	}
+	err := CompareJSONStrings(src, dst, &Options{Paths: map[string]*PathOptions{"$.address": {Strict: new(bool)}}})
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("wanted: '*MismatchError', got '%v'", err)
+	}
+	if len(mismatch.Mismatches) != len(wanted)-1 {
+		t.Fatalf("wanted: %d mismatches, got %d (%v)", len(wanted)-1, len(mismatch.Mismatches), err)
+	}
+	err = CompareJSONStrings(src, dst, &Options{Strict: true})
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("wanted: '*MismatchError', got '%v'", err)
+	}
+	if len(mismatch.Mismatches) != len(wanted) {
+		t.Fatalf("wanted: %d mismatches, got %d (%v)", len(wanted), len(mismatch.Mismatches), err)
+	}
+	for i, m := range mismatch.Mismatches {
+		if m.String() != wanted[i] {
+			t.Errorf("wanted: '%s', got '%s'", wanted[i], m.String())
+		}
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	tests := []struct {
+		name            string
+		src             string
+		dst             string
+		caseInsensitive bool
+		result          error
+	}{
+		{
+			name:   "identical",
+			src:    "42",
+			dst:    "42",
+			result: nil,
+		},
+		{
+			name:   "not json",
+			src:    "4",
+			dst:    "42",
+			result: nil,
+		},
+		{
+			name:   "regular expression",
+			src:    "^\\d+$",
+			dst:    "42",
+			result: nil,
+		},
+		{
+			name:   "different",
+			src:    "^ok$",
+			dst:    "OK",
+			result: ErrJSONMismatched,
+		},
+		{
+			name:            "case insensitive",
+			src:             "^ok$",
+			dst:             "OK",
+			caseInsensitive: true,
+			result:          nil,
+		},
+	}
+	for _, tt := range tests {
+		src, dst, caseInsensitive, res := tt.src, tt.dst, tt.caseInsensitive, tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CompareStrings(src, dst, caseInsensitive)
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package json
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// matcher checks a got value against a matcher token
+// such as "$uuid" or "$gt:0" found in the wanted document.
+type matcher func(got any, caseInsensitive bool) (bool, error)
+
+func lengthOf(value any) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return len([]rune(v)), true
+	case []any:
+		return len(v), true
+	case map[string]any:
+		return len(v), true
+	}
+	return 0, false
+}
+
+func numberMatcher(argument string, accept func(got, wanted float64) bool) matcher {
+	return func(got any, _ bool) (bool, error) {
+		wanted, err := strconv.ParseFloat(argument, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number '%s'", argument)
+		}
+		g, ok := toFloat(got)
+		return ok && accept(g, wanted), nil
+	}
+}
+
+func stringMatcher(accept func(got string) bool) matcher {
+	return func(got any, _ bool) (bool, error) {
+		s, ok := got.(string)
+		return ok && accept(s), nil
+	}
+}
+
+// parseMatcher returns the matcher corresponding to the token,
+// if the token is a matcher. Tokens starting with $$ are not
+// matchers, but literal strings starting with $.
+func parseMatcher(token string) (matcher, bool) {
+	if !strings.HasPrefix(token, "$") || strings.HasPrefix(token, "$$") {
+		return nil, false
+	}
+	name, argument, _ := strings.Cut(token[1:], ":")
+	switch name {
+	case "any":
+		return func(any, bool) (bool, error) { return true, nil }, true
+	case "type":
+		return func(got any, _ bool) (bool, error) { return hasType(argument, got), nil }, true
+	case "uuid":
+		return stringMatcher(uuidRegexp.MatchString), true
+	case "datetime":
+		return stringMatcher(func(got string) bool {
+			_, err := time.Parse(time.RFC3339, got)
+			return err == nil
+		}), true
+	case "date":
+		return stringMatcher(func(got string) bool {
+			_, err := time.Parse(time.DateOnly, got)
+			return err == nil
+		}), true
+	case "regex":
+		return func(got any, caseInsensitive bool) (bool, error) {
+			pattern := argument
+			if caseInsensitive {
+				pattern = fmt.Sprintf("(?i)%s", pattern)
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, fmt.Errorf("invalid regular expression '%s'", argument)
+			}
+			s, ok := got.(string)
+			if !ok {
+				s = display(got)
+			}
+			return re.MatchString(s), nil
+		}, true
+	case "gt":
+		return numberMatcher(argument, func(got, wanted float64) bool { return got > wanted }), true
+	case "gte":
+		return numberMatcher(argument, func(got, wanted float64) bool { return got >= wanted }), true
+	case "lt":
+		return numberMatcher(argument, func(got, wanted float64) bool { return got < wanted }), true
+	case "lte":
+		return numberMatcher(argument, func(got, wanted float64) bool { return got <= wanted }), true
+	case "len":
+		return func(got any, _ bool) (bool, error) {
+			wanted, err := strconv.Atoi(argument)
+			if err != nil {
+				return false, fmt.Errorf("invalid length '%s'", argument)
+			}
+			length, ok := lengthOf(got)
+			return ok && length == wanted, nil
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,146 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Tolerance represents how much two numbers can differ and
+// still be considered equal. The difference must be lower
+// than or equal to Absolute + Relative * |wanted|.
+type Tolerance struct {
+	Absolute float64
+	Relative float64
+}
+
+// decode decodes the JSON document, keeping numbers as
+// json.Number to avoid losing precision.
+func decode(data string) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return document, nil
+}
+
+func toRat(value any) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(v.String())
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(v), true
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	}
+	return nil, false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// IsNumber returns true if the value is a number.
+func IsNumber(value any) bool {
+	_, ok := toRat(value)
+	return ok
+}
+
+// ParseNumber returns the value as an exact number. Unlike in
+// JSON documents, strings (e.g. the text of an XML element)
+// are parsed as numbers.
+func ParseNumber(value any) (*big.Rat, bool) {
+	if s, ok := value.(string); ok {
+		s = strings.TrimSpace(s)
+		// reject fractions (e.g. 1/2), which big.Rat accepts
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, false
+		}
+		return new(big.Rat).SetString(s)
+	}
+	return toRat(value)
+}
+
+func isInteger(value any) bool {
+	r, ok := toRat(value)
+	return ok && r.IsInt()
+}
+
+// compareNumbers returns true if the numbers are equal,
+// exactly (even for large integers) if tolerance is nil.
+func compareNumbers(wanted, got any, tolerance *Tolerance) bool {
+	w, ok := toRat(wanted)
+	if !ok {
+		return false
+	}
+	g, ok := toRat(got)
+	if !ok {
+		return false
+	}
+	if w.Cmp(g) == 0 {
+		return true
+	}
+	if tolerance == nil {
+		return false
+	}
+	wf, _ := w.Float64()
+	diff, _ := new(big.Rat).Sub(w, g).Float64()
+	return math.Abs(diff) <= tolerance.Absolute+tolerance.Relative*math.Abs(wf)
+}
+
+// equalValues returns true if the values are deeply equal,
+// numbers being compared by value whatever their Go type.
+func equalValues(a, b any) bool {
+	if IsNumber(a) || IsNumber(b) {
+		return compareNumbers(a, b, nil)
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalValues(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, found := b[key]
+			if !found || !equalValues(value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,211 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	segmentKey segmentKind = iota
+	segmentIndex
+	segmentWildcard
+	segmentRecursive
+	segmentLength
+)
+
+type segment struct {
+	kind  segmentKind
+	key   string
+	index int
+}
+
+func parsePath(path string) ([]segment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid path '%s': must start with $", path)
+	}
+	var segments []segment
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".length()"):
+			segments = append(segments, segment{kind: segmentLength})
+			rest = rest[len(".length()"):]
+			if rest != "" {
+				return nil, fmt.Errorf("invalid path '%s': length() must be last", path)
+			}
+		case strings.HasPrefix(rest, ".."):
+			rest = rest[2:]
+			key, n := readKey(rest)
+			if key == "" {
+				return nil, fmt.Errorf("invalid path '%s': missing key after ..", path)
+			}
+			segments = append(segments, segment{kind: segmentRecursive, key: key})
+			rest = rest[n:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			key, n := readKey(rest)
+			if key == "" {
+				return nil, fmt.Errorf("invalid path '%s': missing key after .", path)
+			}
+			if key == "*" {
+				segments = append(segments, segment{kind: segmentWildcard})
+			} else {
+				segments = append(segments, segment{kind: segmentKey, key: key})
+			}
+			rest = rest[n:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path '%s': missing ]", path)
+			}
+			content := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case content == "*":
+				segments = append(segments, segment{kind: segmentWildcard})
+			case len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0]:
+				segments = append(segments, segment{kind: segmentKey, key: content[1 : len(content)-1]})
+			default:
+				index, err := strconv.Atoi(content)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path '%s': invalid index '%s'", path, content)
+				}
+				segments = append(segments, segment{kind: segmentIndex, index: index})
+			}
+		default:
+			return nil, fmt.Errorf("invalid path '%s': unexpected '%s'", path, rest)
+		}
+	}
+	return segments, nil
+}
+
+func readKey(value string) (string, int) {
+	n := strings.IndexAny(value, ".[")
+	if n == -1 {
+		n = len(value)
+	}
+	return value[:n], n
+}
+
+func descendants(value any) []any {
+	result := []any{value}
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range SortedKeys(v) {
+			result = append(result, descendants(v[key])...)
+		}
+	case []any:
+		for _, element := range v {
+			result = append(result, descendants(element)...)
+		}
+	}
+	return result
+}
+
+func apply(values []any, seg segment) []any {
+	var result []any
+	for _, value := range values {
+		switch seg.kind {
+		case segmentKey:
+			if obj, ok := value.(map[string]any); ok {
+				if v, found := obj[seg.key]; found {
+					result = append(result, v)
+				}
+			}
+		case segmentIndex:
+			if array, ok := value.([]any); ok {
+				index := seg.index
+				if index < 0 {
+					index += len(array)
+				}
+				if index >= 0 && index < len(array) {
+					result = append(result, array[index])
+				}
+			}
+		case segmentWildcard:
+			switch v := value.(type) {
+			case map[string]any:
+				for _, key := range SortedKeys(v) {
+					result = append(result, v[key])
+				}
+			case []any:
+				result = append(result, v...)
+			}
+		case segmentRecursive:
+			for _, d := range descendants(value) {
+				if obj, ok := d.(map[string]any); ok {
+					if v, found := obj[seg.key]; found {
+						result = append(result, v)
+					}
+				}
+			}
+		case segmentLength:
+			switch v := value.(type) {
+			case map[string]any:
+				result = append(result, float64(len(v)))
+			case []any:
+				result = append(result, float64(len(v)))
+			case string:
+				result = append(result, float64(len([]rune(v))))
+			}
+		}
+	}
+	return result
+}
+
+// Query returns all the values of document matching the
+// provided JSONPath-like path.
+//
+// The supported syntax is: $ (root), .key or ['key'] (member),
+// [n] (array element, negative indices start from the end),
+// .* or [*] (all members or elements), ..key (recursive descent)
+// and a trailing .length() (length of an array, object or string).
+func Query(document any, path string) ([]any, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	values := []any{document}
+	for _, seg := range segments {
+		values = apply(values, seg)
+	}
+	return values, nil
+}
+
+// matchPrefix returns true if the location is matched by the
+// pattern, or is a child of a location matched by the pattern.
+func matchPrefix(pattern, location []segment) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	p := pattern[0]
+	if p.kind == segmentRecursive {
+		for i, seg := range location {
+			if seg.kind == segmentKey && seg.key == p.key && matchPrefix(pattern[1:], location[i+1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(location) == 0 {
+		return false
+	}
+	seg := location[0]
+	switch p.kind {
+	case segmentWildcard:
+	case segmentKey:
+		if seg.kind != segmentKey || seg.key != p.key {
+			return false
+		}
+	case segmentIndex:
+		if seg.kind != segmentIndex || seg.index != p.index {
+			return false
+		}
+	default:
+		return false
+	}
+	return matchPrefix(pattern[1:], location[1:])
+}
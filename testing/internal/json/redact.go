@@ -0,0 +1,101 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ValidatePath returns an error if the JSONPath-like path is
+// invalid.
+func ValidatePath(path string) error {
+	_, err := parsePath(path)
+	return err
+}
+
+func remove(value any, segments []segment) any {
+	if len(segments) == 0 {
+		return value
+	}
+	seg, last := segments[0], len(segments) == 1
+	switch seg.kind {
+	case segmentKey:
+		if obj, ok := value.(map[string]any); ok {
+			if _, found := obj[seg.key]; found {
+				if last {
+					delete(obj, seg.key)
+				} else {
+					obj[seg.key] = remove(obj[seg.key], segments[1:])
+				}
+			}
+		}
+	case segmentIndex:
+		if array, ok := value.([]any); ok {
+			index := seg.index
+			if index < 0 {
+				index += len(array)
+			}
+			if index >= 0 && index < len(array) {
+				if last {
+					return append(array[:index:index], array[index+1:]...)
+				}
+				array[index] = remove(array[index], segments[1:])
+			}
+		}
+	case segmentWildcard:
+		switch v := value.(type) {
+		case map[string]any:
+			for key := range v {
+				if last {
+					delete(v, key)
+				} else {
+					v[key] = remove(v[key], segments[1:])
+				}
+			}
+		case []any:
+			if last {
+				return []any{}
+			}
+			for i := range v {
+				v[i] = remove(v[i], segments[1:])
+			}
+		}
+	case segmentRecursive:
+		for _, d := range descendants(value) {
+			if obj, ok := d.(map[string]any); ok {
+				if _, found := obj[seg.key]; found {
+					if last {
+						delete(obj, seg.key)
+					} else {
+						obj[seg.key] = remove(obj[seg.key], segments[1:])
+					}
+				}
+			}
+		}
+	}
+	return value
+}
+
+// Redact removes all the values matching the provided
+// JSONPath-like paths from the content JSON document and
+// returns it pretty-printed.
+func Redact(content string, paths []string) (string, error) {
+	document, err := decode(content)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range paths {
+		segments, err := parsePath(path)
+		if err != nil {
+			return "", err
+		}
+		document = remove(document, segments)
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,63 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		paths   []string
+		result  string
+	}{
+		{
+			name:    "no paths",
+			content: "{\"id\":12345678901234567890,\"name\":\"<b>\"}",
+			result:  "{\n  \"id\": 12345678901234567890,\n  \"name\": \"<b>\"\n}\n",
+		},
+		{
+			name:    "key",
+			content: "{\"id\":1,\"createdAt\":\"2023-06-01T10:00:00Z\"}",
+			paths:   []string{"$.createdAt"},
+			result:  "{\n  \"id\": 1\n}\n",
+		},
+		{
+			name:    "missing key",
+			content: "{\"id\":1}",
+			paths:   []string{"$.user.createdAt"},
+			result:  "{\n  \"id\": 1\n}\n",
+		},
+		{
+			name:    "wildcard",
+			content: "{\"items\":[{\"id\":1,\"at\":\"x\"},{\"id\":2,\"at\":\"y\"}]}",
+			paths:   []string{"$.items[*].at"},
+			result:  "{\n  \"items\": [\n    {\n      \"id\": 1\n    },\n    {\n      \"id\": 2\n    }\n  ]\n}\n",
+		},
+		{
+			name:    "recursive",
+			content: "{\"at\":\"x\",\"user\":{\"id\":1,\"at\":\"y\"}}",
+			paths:   []string{"$..at"},
+			result:  "{\n  \"user\": {\n    \"id\": 1\n  }\n}\n",
+		},
+		{
+			name:    "index",
+			content: "[1,2,3]",
+			paths:   []string{"$[-1]"},
+			result:  "[\n  1,\n  2\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		content, paths, res := tt.content, tt.paths, tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := Redact(content, paths)
+			if err != nil {
+				t.Fatalf("cannot redact: %v", err)
+			}
+			if result != res {
+				t.Errorf("wanted: '%s', got '%s'", res, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrSchemaViolated error = errors.New("schema violated")
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type violation struct {
+	pointer string
+	message string
+}
+
+type validator struct {
+	root       any
+	violations []violation
+	depth      int
+}
+
+func pointerEscape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func pointerUnescape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+}
+
+func (v *validator) fail(pointer, format string, args ...any) {
+	if pointer == "" {
+		pointer = "/"
+	}
+	v.violations = append(v.violations, violation{pointer: pointer, message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) resolve(ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref '%s': only local references are supported", ref)
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, err
+	}
+	current := v.root
+	if fragment == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		token = pointerUnescape(token)
+		switch node := current.(type) {
+		case map[string]any:
+			var found bool
+			if current, found = node[token]; !found {
+				return nil, fmt.Errorf("cannot resolve $ref '%s'", ref)
+			}
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("cannot resolve $ref '%s'", ref)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot resolve $ref '%s'", ref)
+		}
+	}
+	return current, nil
+}
+
+// matches returns true if the instance is valid against the
+// schema, without recording any violation.
+func (v *validator) matches(schema, instance any, pointer string) bool {
+	sub := &validator{root: v.root, depth: v.depth}
+	sub.validate(schema, instance, pointer)
+	return len(sub.violations) == 0
+}
+
+func hasType(name string, instance any) bool {
+	if name == "integer" {
+		return isInteger(instance)
+	}
+	return typeOf(instance) == name
+}
+
+func checkFormat(format, value string) bool {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	case "time":
+		_, err = time.Parse("15:04:05Z07:00", value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "uri":
+		var u *url.URL
+		u, err = url.Parse(value)
+		if err == nil && !u.IsAbs() {
+			return false
+		}
+	case "uuid":
+		return uuidRegexp.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && strings.Contains(value, ".")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	}
+	return err == nil
+}
+
+func (v *validator) validate(schema, instance any, pointer string) {
+	if v.depth > 100 {
+		v.fail(pointer, "schema is too deeply nested (circular $ref?)")
+		return
+	}
+	v.depth++
+	defer func() { v.depth-- }()
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(pointer, "no value allowed")
+		}
+		return
+	case map[string]any:
+		v.validateObject(s, instance, pointer)
+	default:
+		v.fail(pointer, "invalid schema")
+	}
+}
+
+func (v *validator) validateObject(schema map[string]any, instance any, pointer string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			v.fail(pointer, "%v", err)
+		} else {
+			v.validate(target, instance, pointer)
+		}
+	}
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []any:
+			for _, name := range t {
+				if name, ok := name.(string); ok {
+					types = append(types, name)
+				}
+			}
+		}
+		valid := false
+		for _, name := range types {
+			if hasType(name, instance) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			v.fail(pointer, "expected type %s, got %s", strings.Join(types, " or "), typeOf(instance))
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		valid := false
+		for _, value := range enum {
+			if equalValues(value, instance) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			v.fail(pointer, "value %s is not one of %s", display(instance), display(enum))
+		}
+	}
+	if value, ok := schema["const"]; ok && !equalValues(value, instance) {
+		v.fail(pointer, "value %s is not %s", display(instance), display(value))
+	}
+	v.validateCombinators(schema, instance, pointer)
+	switch instance := instance.(type) {
+	case json.Number, float64:
+		v.validateNumber(schema, instance, pointer)
+	case string:
+		v.validateString(schema, instance, pointer)
+	case []any:
+		v.validateArray(schema, instance, pointer)
+	case map[string]any:
+		v.validateMap(schema, instance, pointer)
+	}
+}
+
+func (v *validator) validateCombinators(schema map[string]any, instance any, pointer string) {
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, sub := range allOf {
+			v.validate(sub, instance, pointer)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		valid := false
+		for _, sub := range anyOf {
+			if v.matches(sub, instance, pointer) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			v.fail(pointer, "value does not match any schema of anyOf")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		count := 0
+		for _, sub := range oneOf {
+			if v.matches(sub, instance, pointer) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.fail(pointer, "value matches %d schemas of oneOf instead of exactly one", count)
+		}
+	}
+	if not, ok := schema["not"]; ok && v.matches(not, instance, pointer) {
+		v.fail(pointer, "value must not match the schema of not")
+	}
+	if cond, ok := schema["if"]; ok {
+		if v.matches(cond, instance, pointer) {
+			if then, ok := schema["then"]; ok {
+				v.validate(then, instance, pointer)
+			}
+		} else if otherwise, ok := schema["else"]; ok {
+			v.validate(otherwise, instance, pointer)
+		}
+	}
+}
+
+// validateNumber compares numbers exactly, so that large
+// integers and decimals do not lose precision.
+func (v *validator) validateNumber(schema map[string]any, instance any, pointer string) {
+	number, ok := toRat(instance)
+	if !ok {
+		return
+	}
+	if minimum, ok := toRat(schema["minimum"]); ok && number.Cmp(minimum) < 0 {
+		v.fail(pointer, "value %v is less than minimum %v", instance, schema["minimum"])
+	}
+	if maximum, ok := toRat(schema["maximum"]); ok && number.Cmp(maximum) > 0 {
+		v.fail(pointer, "value %v is greater than maximum %v", instance, schema["maximum"])
+	}
+	if minimum, ok := toRat(schema["exclusiveMinimum"]); ok && number.Cmp(minimum) <= 0 {
+		v.fail(pointer, "value %v is less than or equal to exclusiveMinimum %v", instance, schema["exclusiveMinimum"])
+	}
+	if maximum, ok := toRat(schema["exclusiveMaximum"]); ok && number.Cmp(maximum) >= 0 {
+		v.fail(pointer, "value %v is greater than or equal to exclusiveMaximum %v", instance, schema["exclusiveMaximum"])
+	}
+	if multiple, ok := toRat(schema["multipleOf"]); ok && multiple.Sign() > 0 {
+		if !new(big.Rat).Quo(number, multiple).IsInt() {
+			v.fail(pointer, "value %v is not a multiple of %v", instance, schema["multipleOf"])
+		}
+	}
+}
+
+func (v *validator) validateString(schema map[string]any, instance string, pointer string) {
+	length := float64(len([]rune(instance)))
+	if minimum, ok := toFloat(schema["minLength"]); ok && length < minimum {
+		v.fail(pointer, "length %v is less than minLength %v", length, minimum)
+	}
+	if maximum, ok := toFloat(schema["maxLength"]); ok && length > maximum {
+		v.fail(pointer, "length %v is greater than maxLength %v", length, maximum)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		matched, err := regexp.MatchString(pattern, instance)
+		if err != nil {
+			v.fail(pointer, "invalid pattern '%s': %v", pattern, err)
+		} else if !matched {
+			v.fail(pointer, "value %s does not match pattern '%s'", display(instance), pattern)
+		}
+	}
+	if format, ok := schema["format"].(string); ok && !checkFormat(format, instance) {
+		v.fail(pointer, "value %s is not a valid %s", display(instance), format)
+	}
+}
+
+func (v *validator) validateArray(schema map[string]any, instance []any, pointer string) {
+	length := float64(len(instance))
+	if minimum, ok := toFloat(schema["minItems"]); ok && length < minimum {
+		v.fail(pointer, "array has %v items, less than minItems %v", length, minimum)
+	}
+	if maximum, ok := toFloat(schema["maxItems"]); ok && length > maximum {
+		v.fail(pointer, "array has %v items, more than maxItems %v", length, maximum)
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := 0; i < len(instance); i++ {
+			for j := i + 1; j < len(instance); j++ {
+				if equalValues(instance[i], instance[j]) {
+					v.fail(fmt.Sprintf("%s/%d", pointer, j), "duplicate of item %d", i)
+				}
+			}
+		}
+	}
+	prefix := 0
+	if prefixItems, ok := schema["prefixItems"].([]any); ok {
+		for i, sub := range prefixItems {
+			if i >= len(instance) {
+				break
+			}
+			v.validate(sub, instance[i], fmt.Sprintf("%s/%d", pointer, i))
+		}
+		prefix = len(prefixItems)
+	}
+	if items, ok := schema["items"]; ok {
+		for i := prefix; i < len(instance); i++ {
+			v.validate(items, instance[i], fmt.Sprintf("%s/%d", pointer, i))
+		}
+	}
+	if contains, ok := schema["contains"]; ok {
+		count := 0
+		for i, item := range instance {
+			if v.matches(contains, item, fmt.Sprintf("%s/%d", pointer, i)) {
+				count++
+			}
+		}
+		minimum := 1.0
+		if m, ok := toFloat(schema["minContains"]); ok {
+			minimum = m
+		}
+		if float64(count) < minimum {
+			v.fail(pointer, "array contains %d matching items, less than %v", count, minimum)
+		}
+		if maximum, ok := toFloat(schema["maxContains"]); ok && float64(count) > maximum {
+			v.fail(pointer, "array contains %d matching items, more than %v", count, maximum)
+		}
+	}
+}
+
+func (v *validator) validateMap(schema map[string]any, instance map[string]any, pointer string) {
+	length := float64(len(instance))
+	if minimum, ok := toFloat(schema["minProperties"]); ok && length < minimum {
+		v.fail(pointer, "object has %v properties, less than minProperties %v", length, minimum)
+	}
+	if maximum, ok := toFloat(schema["maxProperties"]); ok && length > maximum {
+		v.fail(pointer, "object has %v properties, more than maxProperties %v", length, maximum)
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, key := range required {
+			if key, ok := key.(string); ok {
+				if _, found := instance[key]; !found {
+					v.fail(pointer, "missing required property '%s'", key)
+				}
+			}
+		}
+	}
+	if dependentRequired, ok := schema["dependentRequired"].(map[string]any); ok {
+		for _, key := range SortedKeys(dependentRequired) {
+			if _, found := instance[key]; !found {
+				continue
+			}
+			if required, ok := dependentRequired[key].([]any); ok {
+				for _, dependency := range required {
+					if dependency, ok := dependency.(string); ok {
+						if _, found := instance[dependency]; !found {
+							v.fail(pointer, "property '%s' requires property '%s'", key, dependency)
+						}
+					}
+				}
+			}
+		}
+	}
+	if dependentSchemas, ok := schema["dependentSchemas"].(map[string]any); ok {
+		for _, key := range SortedKeys(dependentSchemas) {
+			if _, found := instance[key]; found {
+				v.validate(dependentSchemas[key], instance, pointer)
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	patternProperties, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	propertyNames, hasPropertyNames := schema["propertyNames"]
+	for _, key := range SortedKeys(instance) {
+		child := fmt.Sprintf("%s/%s", pointer, pointerEscape(key))
+		if hasPropertyNames && !v.matches(propertyNames, key, child) {
+			v.fail(child, "invalid property name '%s'", key)
+		}
+		evaluated := false
+		if sub, ok := properties[key]; ok {
+			v.validate(sub, instance[key], child)
+			evaluated = true
+		}
+		for _, pattern := range SortedKeys(patternProperties) {
+			matched, err := regexp.MatchString(pattern, key)
+			if err != nil {
+				v.fail(pointer, "invalid pattern '%s': %v", pattern, err)
+				continue
+			}
+			if matched {
+				v.validate(patternProperties[pattern], instance[key], child)
+				evaluated = true
+			}
+		}
+		if !evaluated && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(child, "additional property '%s' is not allowed", key)
+			} else {
+				v.validate(additional, instance[key], child)
+			}
+		}
+	}
+}
+
+// CheckSchema returns an error if the provided schema
+// is not a valid JSON document.
+func CheckSchema(schema string) error {
+	s, err := decode(schema)
+	if err != nil {
+		return fmt.Errorf("invalid json schema: %w", err)
+	}
+	switch s.(type) {
+	case bool, map[string]any:
+		return nil
+	}
+	return fmt.Errorf("invalid json schema: must be an object or a boolean")
+}
+
+// ValidateSchema validates the got JSON document against the
+// provided JSON Schema (draft 2020-12) and returns all the
+// violations, each of them reported with its JSON pointer.
+//
+// Only local references ($ref: #/...) are supported, and
+// unevaluatedItems/unevaluatedProperties are ignored.
+func ValidateSchema(schema, got string) error {
+	if schema == "" {
+		return nil
+	}
+	s, err := decode(schema)
+	if err != nil {
+		return fmt.Errorf("invalid json schema: %w", err)
+	}
+	document, err := decode(got)
+	if err != nil {
+		return fmt.Errorf("%w: response is not valid json: %v", ErrSchemaViolated, err)
+	}
+	v := &validator{root: s}
+	v.validate(s, document, "")
+	var errs []error
+	for _, violation := range v.violations {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", ErrSchemaViolated, violation.pointer, violation.message))
+	}
+	return errors.Join(errs...)
+}
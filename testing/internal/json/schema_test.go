@@ -0,0 +1,144 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema := `{
+		"$defs": {
+			"item": {
+				"type": "object",
+				"required": ["id", "name"],
+				"properties": {
+					"id": {"type": "integer", "minimum": 1},
+					"name": {"type": "string", "minLength": 1},
+					"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true}
+				},
+				"additionalProperties": false
+			}
+		},
+		"type": "object",
+		"required": ["items"],
+		"properties": {
+			"items": {"type": "array", "items": {"$ref": "#/$defs/item"}},
+			"next": {"type": ["string", "null"], "format": "uri"}
+		}
+	}`
+	tests := []struct {
+		name     string
+		got      string
+		pointers []string
+	}{
+		{
+			name: "valid",
+			got:  `{"items":[{"id":1,"name":"a","tags":["x","y"]},{"id":2,"name":"b"}],"next":null}`,
+		},
+		{
+			name:     "missing required",
+			got:      `{"next":"https://example.com/?page=2"}`,
+			pointers: []string{"/: missing required property 'items'"},
+		},
+		{
+			name: "several violations",
+			got:  `{"items":[{"id":1.5,"name":""},{"id":2,"name":"b","tags":["x","x"],"password":"p"}],"next":"page2"}`,
+			pointers: []string{
+				"/items/0/id: expected type integer",
+				"/items/0/name: length 0 is less than minLength 1",
+				"/items/1/password: additional property",
+				"/items/1/tags/1: duplicate of item 0",
+				"/next: value \"page2\" is not a valid uri",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := tt.got
+		pointers := tt.pointers
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateSchema(schema, got)
+			if len(pointers) == 0 {
+				if err != nil {
+					t.Errorf("wanted: no error, got '%v'", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrSchemaViolated) {
+				t.Fatalf("wanted: '%v', got '%v'", ErrSchemaViolated, err)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(pointers) {
+				t.Fatalf("wanted: %d violations, got %d (%v)", len(pointers), len(lines), err)
+			}
+			for i, pointer := range pointers {
+				if !strings.Contains(lines[i], pointer) {
+					t.Errorf("wanted: '%s', got '%s'", pointer, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSchemaNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		got    string
+		result error
+	}{
+		{
+			name:   "large maximum",
+			schema: `{"maximum": 12345678901234566}`,
+			got:    `12345678901234567`,
+			result: ErrSchemaViolated,
+		},
+		{
+			name:   "large maximum equal",
+			schema: `{"maximum": 12345678901234567}`,
+			got:    `12345678901234567`,
+			result: nil,
+		},
+		{
+			name:   "large exclusive minimum",
+			schema: `{"exclusiveMinimum": 12345678901234567}`,
+			got:    `12345678901234567`,
+			result: ErrSchemaViolated,
+		},
+		{
+			name:   "decimal minimum",
+			schema: `{"minimum": 0.3}`,
+			got:    `0.30000000000000004`,
+			result: nil,
+		},
+		{
+			name:   "multiple of decimal",
+			schema: `{"multipleOf": 0.01}`,
+			got:    `19.99`,
+			result: nil,
+		},
+		{
+			name:   "not a multiple of decimal",
+			schema: `{"multipleOf": 0.01}`,
+			got:    `19.999`,
+			result: ErrSchemaViolated,
+		},
+		{
+			name:   "not a multiple of large integer",
+			schema: `{"multipleOf": 2}`,
+			got:    `12345678901234567`,
+			result: ErrSchemaViolated,
+		},
+	}
+	for _, tt := range tests {
+		schema, got, res := tt.schema, tt.got, tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateSchema(schema, got)
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
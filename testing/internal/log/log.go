@@ -1,12 +1,52 @@
 package log
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	output  io.Writer = os.Stdout
+	colored           = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+)
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func SetOutput(w io.Writer) {
+	output = w
+}
+
+func DisableColors() {
+	colored = false
+}
+
+func color(code, value string) string {
+	if !colored {
+		return value
+	}
+	return fmt.Sprintf("\033[%sm%s\033[0m", code, value)
+}
+
+func Red(value string) string {
+	return color("31", value)
+}
+
+func Green(value string) string {
+	return color("32", value)
+}
 
 func Printf(format string, args ...any) {
-	fmt.Printf(format, args...)
+	fmt.Fprintf(output, format, args...)
 }
 
 func Fatalf(format string, args ...any) {
 	format = fmt.Sprintf("FAIL\t%s", format)
-	fmt.Printf(format, args...)
+	fmt.Fprintf(output, format, args...)
 }
@@ -1,6 +1,7 @@
 package os
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -46,9 +47,21 @@ func findValue(key string, captures map[string]any) string {
 	}
 	v, ok := captures[key]
 	if !ok {
-		return key
+		// keys used to be lowercased, keep a case
+		// insensitive lookup for compatibility
+		for k, value := range captures {
+			if strings.EqualFold(k, key) {
+				v, ok = value, true
+				break
+			}
+		}
+		if !ok {
+			return key
+		}
 	}
 	switch value := v.(type) {
+	case json.Number:
+		return value.String()
 	case float64:
 		return fmt.Sprintf("%0.0f", value)
 	case string:
@@ -60,6 +73,8 @@ func findValue(key string, captures map[string]any) string {
 			return "array index out of bounds"
 		}
 		switch element := value[index].(type) {
+		case json.Number:
+			return element.String()
 		case float64:
 			return fmt.Sprintf("%0.0f", element)
 		case string:
@@ -90,3 +105,15 @@ func SubstituteCapturedVariable(value string, captures map[string]any) string {
 	}
 	return result
 }
+
+// UnmarshalCapture decodes a captured response, keeping numbers
+// as json.Number so that large ids do not lose precision.
+func UnmarshalCapture(response string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(response))
+	decoder.UseNumber()
+	var capture any
+	if err := decoder.Decode(&capture); err != nil {
+		return nil, err
+	}
+	return capture, nil
+}
@@ -0,0 +1,159 @@
+package xml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ijson "github.com/fred1268/okapi/testing/internal/json"
+)
+
+var ErrAssertionFailed error = errors.New("assertion failed")
+
+// Assertion represents a check on the value(s) found at the
+// XPath Path.
+type Assertion struct {
+	Path     string
+	Operator string
+	Value    any
+}
+
+func text(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func equal(wanted, got any) bool {
+	if ijson.IsNumber(wanted) {
+		w, _ := ijson.ParseNumber(wanted)
+		g, ok := ijson.ParseNumber(got)
+		return ok && w.Cmp(g) == 0
+	}
+	return text(wanted) == text(got)
+}
+
+func check(operator string, wanted, got any) (bool, error) {
+	switch operator {
+	case "==":
+		return equal(wanted, got), nil
+	case "!=":
+		return !equal(wanted, got), nil
+	case "<", "<=", ">", ">=":
+		w, ok := ijson.ParseNumber(wanted)
+		if !ok {
+			return false, fmt.Errorf("%s requires a number", operator)
+		}
+		g, ok := ijson.ParseNumber(got)
+		if !ok {
+			return false, nil
+		}
+		switch operator {
+		case "<":
+			return g.Cmp(w) < 0, nil
+		case "<=":
+			return g.Cmp(w) <= 0, nil
+		case ">":
+			return g.Cmp(w) > 0, nil
+		}
+		return g.Cmp(w) >= 0, nil
+	case "contains":
+		return strings.Contains(text(got), text(wanted)), nil
+	case "matches":
+		pattern, ok := wanted.(string)
+		if !ok {
+			return false, fmt.Errorf("matches requires a string regular expression")
+		}
+		return regexp.MatchString(pattern, text(got))
+	}
+	return false, fmt.Errorf("unknown operator '%s'", operator)
+}
+
+var operators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"contains": true, "matches": true, "exists": true, "notExists": true,
+}
+
+// Validate returns an error if the path or the operator of the
+// assertion is invalid.
+func (a *Assertion) Validate() error {
+	if _, err := parsePath(a.Path); err != nil {
+		return err
+	}
+	if !operators[a.Operator] {
+		return fmt.Errorf("unknown operator '%s'", a.Operator)
+	}
+	switch a.Operator {
+	case "<", "<=", ">", ">=":
+		if _, ok := ijson.ParseNumber(a.Value); !ok {
+			return fmt.Errorf("%s requires a number", a.Operator)
+		}
+	case "matches":
+		pattern, ok := a.Value.(string)
+		if !ok {
+			return fmt.Errorf("matches requires a string regular expression")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Assertion) check(document *node) error {
+	x, err := parsePath(a.Path)
+	if err != nil {
+		return err
+	}
+	values := query(document, x)
+	switch a.Operator {
+	case "exists":
+		if len(values) == 0 {
+			return fmt.Errorf("%w: %s does not exist", ErrAssertionFailed, a.Path)
+		}
+		return nil
+	case "notExists":
+		if len(values) != 0 {
+			return fmt.Errorf("%w: %s exists", ErrAssertionFailed, a.Path)
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%w: %s does not exist", ErrAssertionFailed, a.Path)
+	}
+	for _, value := range values {
+		// the assertion has been validated, so errors come from
+		// the value
+		ok, err := check(a.Operator, a.Value, value)
+		if err != nil {
+			return fmt.Errorf("%w: %s %s %v (got %s): %v", ErrAssertionFailed, a.Path, a.Operator,
+				a.Value, quote(text(value)), err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s %s %v (got %s)", ErrAssertionFailed, a.Path, a.Operator,
+				a.Value, quote(text(value)))
+		}
+	}
+	return nil
+}
+
+// CheckAssertions runs all the assertions against the got XML
+// (or HTML) document and returns the errors of the failing ones.
+func CheckAssertions(got string, html bool, assertions []*Assertion) error {
+	if len(assertions) == 0 {
+		return nil
+	}
+	document, err := parse(got, html)
+	if err != nil {
+		return fmt.Errorf("%w: response is not a valid document: %v", ErrAssertionFailed, err)
+	}
+	var errs []error
+	for _, assertion := range assertions {
+		if err := assertion.check(document); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+package xml
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCheckAssertions(t *testing.T) {
+	document := "<rss version=\"2.0\"><channel><title>News</title><id>12345678901234567</id>" +
+		"<item id=\"1\"><title>first</title><price>10</price></item>" +
+		"<item id=\"2\"><title>second</title><price>20.5</price></item></channel></rss>"
+	tests := []struct {
+		name      string
+		assertion *Assertion
+		result    error
+	}{
+		{
+			name:      "equal",
+			assertion: &Assertion{Path: "/rss/channel/title", Operator: "==", Value: "News"},
+			result:    nil,
+		},
+		{
+			name:      "not equal",
+			assertion: &Assertion{Path: "/rss/channel/title", Operator: "==", Value: "Old"},
+			result:    ErrAssertionFailed,
+		},
+		{
+			name:      "attribute",
+			assertion: &Assertion{Path: "/rss/@version", Operator: "==", Value: "2.0"},
+			result:    nil,
+		},
+		{
+			name:      "position",
+			assertion: &Assertion{Path: "/rss/channel/item[2]/title", Operator: "==", Value: "second"},
+			result:    nil,
+		},
+		{
+			name:      "attribute predicate",
+			assertion: &Assertion{Path: "//item[@id='1']/title/text()", Operator: "==", Value: "first"},
+			result:    nil,
+		},
+		{
+			name:      "descendant",
+			assertion: &Assertion{Path: "//price", Operator: ">", Value: json.Number("5")},
+			result:    nil,
+		},
+		{
+			name:      "descendant failing",
+			assertion: &Assertion{Path: "//price", Operator: "<", Value: json.Number("15")},
+			result:    ErrAssertionFailed,
+		},
+		{
+			name:      "count",
+			assertion: &Assertion{Path: "count(//item)", Operator: "==", Value: json.Number("2")},
+			result:    nil,
+		},
+		{
+			name:      "decimal",
+			assertion: &Assertion{Path: "/rss/channel/item[2]/price", Operator: "==", Value: json.Number("20.50")},
+			result:    nil,
+		},
+		{
+			name:      "large number",
+			assertion: &Assertion{Path: "/rss/channel/id", Operator: "<=", Value: json.Number("12345678901234566")},
+			result:    ErrAssertionFailed,
+		},
+		{
+			name:      "wildcard",
+			assertion: &Assertion{Path: "/rss/channel/*/title", Operator: "matches", Value: "^(first|second)$"},
+			result:    nil,
+		},
+		{
+			name:      "exists",
+			assertion: &Assertion{Path: "/rss/channel/item[@id]", Operator: "exists"},
+			result:    nil,
+		},
+		{
+			name:      "not exists",
+			assertion: &Assertion{Path: "//item[@id='3']", Operator: "notExists"},
+			result:    nil,
+		},
+		{
+			name:      "missing",
+			assertion: &Assertion{Path: "/rss/channel/link", Operator: "contains", Value: "http"},
+			result:    ErrAssertionFailed,
+		},
+	}
+	for _, tt := range tests {
+		assertion := tt.assertion
+		res := tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := assertion.Validate(); err != nil {
+				t.Fatalf("invalid assertion: %v", err)
+			}
+			err := CheckAssertions(document, false, []*Assertion{assertion})
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
+
+func TestValidateAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion *Assertion
+		valid     bool
+	}{
+		{
+			name:      "valid",
+			assertion: &Assertion{Path: "//item/title", Operator: "matches", Value: "^[a-z]+$"},
+			valid:     true,
+		},
+		{
+			name:      "invalid path",
+			assertion: &Assertion{Path: "/rss/[", Operator: "exists"},
+		},
+		{
+			name:      "invalid regular expression",
+			assertion: &Assertion{Path: "//item/title", Operator: "matches", Value: "[a-z"},
+		},
+		{
+			name:      "non numeric value",
+			assertion: &Assertion{Path: "count(//item)", Operator: ">", Value: "many"},
+		},
+	}
+	for _, tt := range tests {
+		assertion, valid := tt.assertion, tt.valid
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := assertion.Validate(); (err == nil) != valid {
+				t.Errorf("wanted: valid %t, got '%v'", valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package xml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type predicate struct {
+	position int
+	attr     string
+	value    *string
+}
+
+type step struct {
+	descendant bool
+	name       string
+	attr       string
+	text       bool
+	predicates []predicate
+}
+
+type xpath struct {
+	steps []step
+	count bool
+}
+
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func parsePredicate(path, content string) (predicate, error) {
+	if !strings.HasPrefix(content, "@") {
+		position, err := strconv.Atoi(content)
+		if err != nil || position < 1 {
+			return predicate{}, fmt.Errorf("invalid path '%s': invalid predicate '%s'", path, content)
+		}
+		return predicate{position: position}, nil
+	}
+	name, value, found := strings.Cut(content[1:], "=")
+	if !found {
+		return predicate{attr: localName(name)}, nil
+	}
+	if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+		return predicate{}, fmt.Errorf("invalid path '%s': invalid predicate '%s'", path, content)
+	}
+	value = value[1 : len(value)-1]
+	return predicate{attr: localName(name), value: &value}, nil
+}
+
+// parseStep parses the step at the beginning of rest, and
+// returns it along with the number of characters read.
+func parseStep(path, rest string) (step, int, error) {
+	var s step
+	n := 0
+	for n < len(rest) && rest[n] != '/' && rest[n] != '[' {
+		n++
+	}
+	name := rest[:n]
+	switch {
+	case name == "":
+		return s, 0, fmt.Errorf("invalid path '%s': empty step", path)
+	case name == "text()":
+		s.text = true
+	case strings.HasPrefix(name, "@"):
+		s.attr = localName(name[1:])
+	default:
+		s.name = localName(name)
+	}
+	for n < len(rest) && rest[n] == '[' {
+		end := strings.Index(rest[n:], "]")
+		if end == -1 {
+			return s, 0, fmt.Errorf("invalid path '%s': missing ]", path)
+		}
+		p, err := parsePredicate(path, rest[n+1:n+end])
+		if err != nil {
+			return s, 0, err
+		}
+		s.predicates = append(s.predicates, p)
+		n += end + 1
+	}
+	if (s.text || s.attr != "") && len(s.predicates) != 0 {
+		return s, 0, fmt.Errorf("invalid path '%s': unexpected predicate", path)
+	}
+	return s, n, nil
+}
+
+func parsePath(path string) (*xpath, error) {
+	x := &xpath{}
+	rest := path
+	if strings.HasPrefix(rest, "count(") && strings.HasSuffix(rest, ")") {
+		x.count = true
+		rest = rest[len("count(") : len(rest)-1]
+	}
+	if !strings.HasPrefix(rest, "/") {
+		return nil, fmt.Errorf("invalid path '%s': must start with /", path)
+	}
+	for rest != "" {
+		descendant := strings.HasPrefix(rest, "//")
+		if descendant {
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, "/") {
+			rest = rest[1:]
+		} else {
+			return nil, fmt.Errorf("invalid path '%s': unexpected '%s'", path, rest)
+		}
+		s, n, err := parseStep(path, rest)
+		if err != nil {
+			return nil, err
+		}
+		if len(x.steps) != 0 {
+			if last := x.steps[len(x.steps)-1]; last.text || last.attr != "" {
+				return nil, fmt.Errorf("invalid path '%s': %s must be last", path, rest)
+			}
+		}
+		s.descendant = descendant
+		x.steps = append(x.steps, s)
+		rest = rest[n:]
+	}
+	return x, nil
+}
+
+func (n *node) descendantsOrSelf() []*node {
+	result := []*node{n}
+	for _, child := range n.children {
+		result = append(result, child.descendantsOrSelf()...)
+	}
+	return result
+}
+
+func (p *predicate) match(n *node) bool {
+	value, found := n.attrs[p.attr]
+	if !found {
+		return false
+	}
+	return p.value == nil || *p.value == value
+}
+
+func (s *step) children(parent *node) []*node {
+	var result []*node
+	for _, child := range parent.children {
+		if s.name == "*" || s.name == child.name {
+			result = append(result, child)
+		}
+	}
+	for _, p := range s.predicates {
+		if p.position != 0 {
+			if p.position > len(result) {
+				return nil
+			}
+			result = result[p.position-1 : p.position]
+			continue
+		}
+		var filtered []*node
+		for _, child := range result {
+			if p.match(child) {
+				filtered = append(filtered, child)
+			}
+		}
+		result = filtered
+	}
+	return result
+}
+
+// query returns the values matching the path: the text content
+// of elements, the value of attributes or the text of elements
+// when using text(), or the number of values when using count().
+func query(root *node, x *xpath) []any {
+	nodes := []*node{root}
+	var values []any
+	for _, s := range x.steps {
+		parents := nodes
+		if s.descendant {
+			parents = nil
+			for _, n := range nodes {
+				parents = append(parents, n.descendantsOrSelf()...)
+			}
+		}
+		switch {
+		case s.text:
+			for _, n := range parents {
+				values = append(values, strings.TrimSpace(n.text))
+			}
+			nodes = nil
+		case s.attr != "":
+			for _, n := range parents {
+				if value, found := n.attrs[s.attr]; found {
+					values = append(values, value)
+				}
+			}
+			nodes = nil
+		default:
+			var children []*node
+			for _, n := range parents {
+				children = append(children, s.children(n)...)
+			}
+			nodes = children
+		}
+	}
+	for _, n := range nodes {
+		values = append(values, n.content())
+	}
+	if x.count {
+		return []any{float64(len(values))}
+	}
+	return values
+}
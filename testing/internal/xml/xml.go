@@ -0,0 +1,255 @@
+package xml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	ijson "github.com/fred1268/okapi/testing/internal/json"
+)
+
+var ErrXMLMismatched error = errors.New("xml mismatched")
+
+// Reasons of a Mismatch specific to XML documents.
+const (
+	ReasonMissingElement   = "missing element"
+	ReasonMissingAttribute = "missing attribute"
+	ReasonInvalidDocument  = ijson.ReasonInvalidDocument
+)
+
+// Options represents the options of the comparison.
+type Options struct {
+	// CaseInsensitive makes the comparison of texts and
+	// attributes case insensitive.
+	CaseInsensitive bool
+	// HTML makes the documents parsed as (non strict) HTML
+	// instead of XML.
+	HTML bool
+	// TextFallback makes wanted compared as text (or as a
+	// regular expression) when it is not a document.
+	TextFallback bool
+}
+
+// MismatchError is returned by CompareXMLStrings when the
+// documents differ. It matches ErrXMLMismatched.
+type MismatchError struct {
+	Mismatches []*ijson.Mismatch
+}
+
+func (e *MismatchError) Error() string {
+	lines := []string{fmt.Sprintf("%s (%d difference(s))", ErrXMLMismatched, len(e.Mismatches))}
+	for _, mismatch := range e.Mismatches {
+		lines = append(lines, mismatch.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *MismatchError) Is(target error) bool {
+	return target == ErrXMLMismatched
+}
+
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string
+}
+
+func normalize(name string, html bool) string {
+	if html {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// parse returns a synthetic node whose children are the
+// top-level elements of the document. Namespaces are ignored.
+func parse(content string, html bool) (*node, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	if html {
+		decoder.Strict = false
+		decoder.AutoClose = xml.HTMLAutoClose
+		decoder.Entity = xml.HTMLEntity
+	}
+	root := &node{}
+	stack := []*node{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// HTML documents are often malformed (e.g. <HTML>
+			// closed by </html>): keep what was parsed so far.
+			if html && len(root.children) != 0 {
+				break
+			}
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &node{name: normalize(t.Name.Local, html), attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				n.attrs[normalize(attr.Name.Local, html)] = attr.Value
+			}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top := stack[len(stack)-1]
+			top.text += string(t)
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("no element found")
+	}
+	return root, nil
+}
+
+// content returns the text of the node and all its descendants.
+func (n *node) content() string {
+	var sb strings.Builder
+	sb.WriteString(n.text)
+	for _, child := range n.children {
+		sb.WriteString(child.content())
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+type comparer struct {
+	caseInsensitive bool
+	mismatches      []*ijson.Mismatch
+}
+
+func (c *comparer) equal(wanted, got string) bool {
+	if c.caseInsensitive {
+		return strings.EqualFold(wanted, got)
+	}
+	return wanted == got
+}
+
+func (c *comparer) mismatch(location, reason, wanted, got string) {
+	c.mismatches = append(c.mismatches, &ijson.Mismatch{Pointer: location, Reason: reason, Wanted: wanted, Got: got})
+}
+
+func quote(value string) string {
+	return strconv.Quote(ijson.Truncate(value))
+}
+
+// compareNodes records the differences between the wanted
+// and got elements. The got element can contain attributes
+// and children which are not in the wanted element.
+func (c *comparer) compareNodes(wanted, got *node, location string) {
+	for _, key := range ijson.SortedKeys(wanted.attrs) {
+		value, found := got.attrs[key]
+		if !found {
+			c.mismatch(location+"/@"+key, ReasonMissingAttribute, quote(wanted.attrs[key]), "(none)")
+			continue
+		}
+		if !c.equal(wanted.attrs[key], value) {
+			c.mismatch(location+"/@"+key, ijson.ReasonValueMismatch, quote(wanted.attrs[key]), quote(value))
+		}
+	}
+	if text := strings.TrimSpace(wanted.text); text != "" {
+		if !c.equal(text, strings.TrimSpace(got.text)) {
+			c.mismatch(location, ijson.ReasonValueMismatch, quote(text), quote(strings.TrimSpace(got.text)))
+		}
+	}
+	used := make([]bool, len(got.children))
+	for _, child := range wanted.children {
+		candidate := -1
+		for i, g := range got.children {
+			if used[i] || g.name != child.name {
+				continue
+			}
+			if candidate == -1 {
+				candidate = i
+			}
+			if c.matches(child, g) {
+				candidate = i
+				break
+			}
+		}
+		if candidate == -1 {
+			c.mismatch(fmt.Sprintf("%s/%s", location, child.name), ReasonMissingElement, "<"+child.name+">", "(none)")
+			continue
+		}
+		used[candidate] = true
+		c.compareNodes(child, got.children[candidate], childLocation(location, got, candidate))
+	}
+}
+
+func (c *comparer) matches(wanted, got *node) bool {
+	sub := &comparer{caseInsensitive: c.caseInsensitive}
+	sub.compareNodes(wanted, got, "")
+	return len(sub.mismatches) == 0
+}
+
+// childLocation returns the XPath of the child of parent at
+// index, with its position among the children of the same name.
+func childLocation(location string, parent *node, index int) string {
+	name := parent.children[index].name
+	position, count := 0, 0
+	for i, child := range parent.children {
+		if child.name != name {
+			continue
+		}
+		count++
+		if i == index {
+			position = count
+		}
+	}
+	if count == 1 {
+		return fmt.Sprintf("%s/%s", location, name)
+	}
+	return fmt.Sprintf("%s/%s[%d]", location, name, position)
+}
+
+// CompareXMLStrings compares the wanted and got XML (or HTML)
+// documents. Every element and attribute of wanted must be found
+// in got, which can contain more, in which case it returns nil.
+// Otherwise, it returns a *MismatchError describing every
+// difference. When options.TextFallback is set and wanted is not
+// a document, got must be identical to wanted, or match it as a
+// regular expression.
+func CompareXMLStrings(wanted, got string, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	if wanted == "" || wanted == got {
+		return nil
+	}
+	w, err := parse(wanted, options.HTML)
+	if err != nil && options.TextFallback {
+		err = ijson.CompareStrings(wanted, got, options.CaseInsensitive)
+		var mismatch *ijson.MismatchError
+		if errors.As(err, &mismatch) {
+			return &MismatchError{Mismatches: mismatch.Mismatches}
+		}
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("invalid expected document: %w", err)
+	}
+	g, err := parse(got, options.HTML)
+	if err != nil {
+		return &MismatchError{Mismatches: []*ijson.Mismatch{{Reason: ReasonInvalidDocument,
+			Wanted: "(valid document)", Got: quote(err.Error())}}}
+	}
+	c := &comparer{caseInsensitive: options.CaseInsensitive}
+	c.compareNodes(w, g, "")
+	if len(c.mismatches) == 0 {
+		return nil
+	}
+	return &MismatchError{Mismatches: c.mismatches}
+}
@@ -0,0 +1,138 @@
+package xml
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompareXML(t *testing.T) {
+	rss := "<?xml version=\"1.0\"?><rss version=\"2.0\"><channel><title>News</title>" +
+		"<item id=\"1\"><title>first</title></item><item id=\"2\"><title>second</title></item></channel></rss>"
+	tests := []struct {
+		name    string
+		src     string
+		dst     string
+		options *Options
+		result  error
+	}{
+		{
+			name:   "identical",
+			src:    rss,
+			dst:    rss,
+			result: nil,
+		},
+		{
+			name:   "subset",
+			src:    "<rss><channel><item><title>second</title></item></channel></rss>",
+			dst:    rss,
+			result: nil,
+		},
+		{
+			name:   "attribute",
+			src:    "<rss version=\"2.0\"><channel><item id=\"2\"/></channel></rss>",
+			dst:    rss,
+			result: nil,
+		},
+		{
+			name:   "different attribute",
+			src:    "<rss version=\"1.0\"/>",
+			dst:    rss,
+			result: ErrXMLMismatched,
+		},
+		{
+			name:   "missing element",
+			src:    "<rss><channel><link/></channel></rss>",
+			dst:    rss,
+			result: ErrXMLMismatched,
+		},
+		{
+			name:   "different text",
+			src:    "<rss><channel><title>Old</title></channel></rss>",
+			dst:    rss,
+			result: ErrXMLMismatched,
+		},
+		{
+			name:    "case insensitive",
+			src:     "<rss><channel><title>NEWS</title></channel></rss>",
+			dst:     rss,
+			options: &Options{CaseInsensitive: true},
+			result:  nil,
+		},
+		{
+			name: "namespaces",
+			src:  "<Envelope><Body><GetPriceResponse><Price>1.90</Price></GetPriceResponse></Body></Envelope>",
+			dst: "<soap:Envelope xmlns:soap=\"http://www.w3.org/2003/05/soap-envelope/\"><soap:Body>" +
+				"<m:GetPriceResponse xmlns:m=\"https://www.w3schools.com/prices\"><m:Price>1.90</m:Price>" +
+				"</m:GetPriceResponse></soap:Body></soap:Envelope>",
+			result: nil,
+		},
+		{
+			name:    "html",
+			src:     "<html><body><h1 class=\"title\">Welcome</h1></body></html>",
+			dst:     "<!DOCTYPE html><HTML><head><meta charset=utf-8><title>Home</title></head><body><H1 class=title>Welcome</H1><br><p>&copy; okapi</body></html>",
+			options: &Options{HTML: true},
+			result:  nil,
+		},
+		{
+			name:   "invalid",
+			src:    "<rss/>",
+			dst:    "{\"rss\":true}",
+			result: ErrXMLMismatched,
+		},
+		{
+			name:    "text fallback",
+			src:     "Not found",
+			dst:     "<html><body><h1>Not found</h1></body></html>",
+			options: &Options{HTML: true, TextFallback: true},
+			result:  nil,
+		},
+		{
+			name:    "regular expression fallback",
+			src:     "^<html>.*[Nn]ot found",
+			dst:     "<html><body><h1>Not found</h1></body></html>",
+			options: &Options{TextFallback: true},
+			result:  nil,
+		},
+		{
+			name:    "regular expression fallback mismatch",
+			src:     "Forbidden",
+			dst:     "<html><body><h1>Not found</h1></body></html>",
+			options: &Options{HTML: true, TextFallback: true},
+			result:  ErrXMLMismatched,
+		},
+	}
+	for _, tt := range tests {
+		src, dst, options, res := tt.src, tt.dst, tt.options, tt.result
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CompareXMLStrings(src, dst, options)
+			if !errors.Is(err, res) || res == nil && err != nil {
+				t.Errorf("wanted: '%v', got '%v'", res, err)
+			}
+		})
+	}
+}
+
+func TestCompareXMLDiff(t *testing.T) {
+	src := "<rss version=\"1.0\"><channel><title>Old</title><item id=\"3\"/><link/></channel></rss>"
+	dst := "<rss version=\"2.0\"><channel><title>News</title><item id=\"1\"/><item id=\"2\"/></channel></rss>"
+	wanted := []string{
+		"/rss/@version: value mismatch, wanted \"1.0\", got \"2.0\"",
+		"/rss/channel/title: value mismatch, wanted \"Old\", got \"News\"",
+		"/rss/channel/item[1]/@id: value mismatch, wanted \"3\", got \"1\"",
+		"/rss/channel/link: missing element, wanted <link>, got (none)",
+	}
+	err := CompareXMLStrings(src, dst, nil)
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("wanted: '*MismatchError', got '%v'", err)
+	}
+	if len(mismatch.Mismatches) != len(wanted) {
+		t.Fatalf("wanted: %d mismatches, got %d (%v)", len(wanted), len(mismatch.Mismatches), err)
+	}
+	for i, m := range mismatch.Mismatches {
+		if m.String() != wanted[i] {
+			t.Errorf("wanted: '%s', got '%s'", wanted[i], m.String())
+		}
+	}
+}
@@ -9,43 +9,67 @@ import (
 	"path"
 	"strings"
 
+	ijson "github.com/fred1268/okapi/testing/internal/json"
 	"github.com/fred1268/okapi/testing/internal/log"
 )
 
-func readJSONDependencies(directory string, requests []*APIRequest) error {
+func readAtFile(directory, name, value, kind string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	file := value[1:]
+	if value == "@file" {
+		file = fmt.Sprintf("%s.%s.json", strings.ToLower(name), kind)
+	}
+	content, err := os.ReadFile(path.Join(directory, file))
+	if err != nil {
+		file = fmt.Sprintf("%s/%s.json", kind, strings.ToLower(name))
+		content, err = os.ReadFile(path.Join(directory, file))
+		if err != nil {
+			return "", fmt.Errorf("cannot read test file '%s': %w", file, err)
+		}
+	}
+	return string(content), nil
+}
+
+func readJSONDependencies(cfg *Config, requests []*APIRequest) error {
+	directory := cfg.Directory
 	for _, request := range requests {
-		if err := request.validate(); err != nil {
+		var err error
+		if err = request.validate(); err != nil {
 			return fmt.Errorf("invalid test '%s': %w", request.Name, err)
 		}
-		if strings.HasPrefix(request.Payload, "@") {
-			file := request.Payload[1:]
-			if request.Payload == "@file" {
-				file = fmt.Sprintf("%s.payload.json", strings.ToLower(request.Name))
-			}
-			content, err := os.ReadFile(path.Join(directory, file))
-			if err != nil {
-				file = fmt.Sprintf("payload/%s.json", strings.ToLower(request.Name))
-				content, err = os.ReadFile(path.Join(directory, file))
-				if err != nil {
-					return fmt.Errorf("cannot read test file '%s': %w", file, err)
-				}
+		if request.Payload, err = readAtFile(directory, request.Name, request.Payload, "payload"); err != nil {
+			return err
+		}
+		if request.Multipart != nil {
+			if err = request.Multipart.readFiles(directory); err != nil {
+				return err
 			}
-			request.Payload = string(content)
 		}
-		if strings.HasPrefix(request.Expected.Response, "@") {
-			file := request.Expected.Response[1:]
-			if request.Expected.Response == "@file" {
-				file = fmt.Sprintf("%s.expected.json", strings.ToLower(request.Name))
+		if request.Expected.snapshot {
+			if request.Expected.Response, err = readSnapshot(cfg, request.Name); err != nil {
+				return err
 			}
-			content, err := os.ReadFile(path.Join(directory, file))
+		} else if request.Expected.Response, err = readAtFile(directory, request.Name, request.Expected.Response,
+			"expected"); err != nil {
+			return err
+		}
+		if request.Expected.Schema, err = readAtFile(directory, request.Name, request.Expected.Schema,
+			"schema"); err != nil {
+			return err
+		}
+		if request.Expected.File != "" {
+			content, err := os.ReadFile(path.Join(directory, request.Expected.File))
 			if err != nil {
-				file = fmt.Sprintf("expected/%s.json", strings.ToLower(request.Name))
-				content, err = os.ReadFile(path.Join(directory, file))
-				if err != nil {
-					return fmt.Errorf("cannot read test file '%s': %w", file, err)
-				}
+				return fmt.Errorf("cannot read test file '%s': %w", request.Expected.File, err)
+			}
+			request.Expected.content = string(content)
+		}
+		if request.Expected.Schema != "" {
+			if err = ijson.CheckSchema(request.Expected.Schema); err != nil {
+				return fmt.Errorf("invalid test '%s': %w", request.Name, err)
 			}
-			request.Expected.Response = string(content)
 		}
 	}
 	return nil
@@ -57,16 +81,23 @@ func loadTest(cfg *Config, uniqueTests map[string]*APIRequest, filename string)
 		return nil, fmt.Errorf("cannot read test file '%s': %w", filename, err)
 	}
 	var tests struct {
-		Tests []*APIRequest
+		Headers map[string]string
+		Tests   []*APIRequest
 	}
-	if err = json.NewDecoder(bytes.NewReader(content)).Decode(&tests); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err = decoder.Decode(&tests); err != nil {
 		return nil, fmt.Errorf("cannot decode json file '%s': %w", filename, err)
 	}
 	for _, test := range tests.Tests {
+		test.fileHeaders = tests.Headers
 		if test.Payload == "@file" {
 			test.atFile = true
 		}
-		if test.Expected.Response == "@file" {
+		if test.Expected.Response == "@snapshot" {
+			test.Expected.snapshot = true
+		}
+		if test.Expected.Response == "@file" || test.Expected.snapshot || test.Expected.Schema == "@file" {
 			test.Expected.atFile = true
 		}
 	}
@@ -87,7 +118,7 @@ func loadTest(cfg *Config, uniqueTests map[string]*APIRequest, filename string)
 			uniqueTests[test.Name] = test
 		}
 	}
-	if err := readJSONDependencies(cfg.Directory, tests.Tests); err != nil {
+	if err := readJSONDependencies(cfg, tests.Tests); err != nil {
 		return nil, err
 	}
 	return tests.Tests, nil
@@ -170,6 +201,7 @@ func LoadClients(ctx context.Context, cfg *Config) (map[string]*Client, error) {
 	}
 	clients := make(map[string]*Client)
 	for key, value := range serverConfigs {
+		value.applyDefaults(cfg)
 		client := NewClient(value)
 		if err := client.config.validate(); err != nil {
 			return nil, fmt.Errorf("server %s: invalid configuration: %w", key, err)
@@ -0,0 +1,141 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLParam represents a query parameter.
+type URLParam struct {
+	Key   string
+	Value string
+}
+
+// URLParams represents the query parameters of a request,
+// in the order they are sent. A key can be repeated.
+//
+// In test files, URLParams can either be an object, whose
+// values are strings or arrays of strings for repeated keys,
+// or an array of {"key": "...", "value": "..."} objects or
+// ["key", "value"] pairs, or a string containing one of them.
+type URLParams []URLParam
+
+func paramValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprintf("%t", v), nil
+	}
+	return "", fmt.Errorf("invalid url parameter value '%v'", value)
+}
+
+func (u *URLParams) unmarshalObject(decoder *json.Decoder) error {
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		var value any
+		if err = decoder.Decode(&value); err != nil {
+			return err
+		}
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, value := range values {
+			s, err := paramValue(value)
+			if err != nil {
+				return err
+			}
+			*u = append(*u, URLParam{Key: key, Value: s})
+		}
+	}
+	return nil
+}
+
+func (u *URLParams) unmarshalArray(decoder *json.Decoder) error {
+	for decoder.More() {
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		var key, param any
+		switch v := value.(type) {
+		case []any:
+			if len(v) != 2 {
+				return fmt.Errorf("invalid url parameter '%v': must be a [key, value] pair", value)
+			}
+			key, param = v[0], v[1]
+		case map[string]any:
+			for k, value := range v {
+				switch strings.ToLower(k) {
+				case "key":
+					key = value
+				case "value":
+					param = value
+				default:
+					return fmt.Errorf("invalid url parameter field '%s'", k)
+				}
+			}
+		default:
+			return fmt.Errorf("invalid url parameter '%v'", value)
+		}
+		k, ok := key.(string)
+		if !ok || k == "" {
+			return fmt.Errorf("invalid url parameter key '%v'", key)
+		}
+		s, err := paramValue(param)
+		if err != nil {
+			return err
+		}
+		*u = append(*u, URLParam{Key: k, Value: s})
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes URLParams from either an object or
+// an array, keeping the order of the parameters.
+func (u *URLParams) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	*u = URLParams{}
+	switch token {
+	case json.Delim('{'):
+		err = u.unmarshalObject(decoder)
+	case json.Delim('['):
+		err = u.unmarshalArray(decoder)
+	case nil:
+		*u = nil
+		return nil
+	default:
+		if content, ok := token.(string); ok && content != "" {
+			return u.UnmarshalJSON([]byte(content))
+		}
+		return fmt.Errorf("invalid url parameters: must be an object or an array")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid url parameters: %w", err)
+	}
+	return nil
+}
+
+// Encode returns the URL encoded parameters, in order.
+func (u URLParams) Encode() string {
+	params := make([]string, 0, len(u))
+	for _, param := range u {
+		params = append(params, fmt.Sprintf("%s=%s", url.QueryEscape(param.Key), url.QueryEscape(param.Value)))
+	}
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,158 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalURLParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		encoded string
+		invalid bool
+	}{
+		{
+			name:    "object",
+			content: "{\"b\":\"2\",\"a\":\"1\",\"c\":\"3\"}",
+			encoded: "b=2&a=1&c=3",
+		},
+		{
+			name:    "repeated keys",
+			content: "{\"id\":[\"1\",\"2\"],\"sort\":\"name\"}",
+			encoded: "id=1&id=2&sort=name",
+		},
+		{
+			name:    "numbers and booleans",
+			content: "{\"page\":2,\"ratio\":0.5,\"all\":true}",
+			encoded: "page=2&ratio=0.5&all=true",
+		},
+		{
+			name:    "pairs",
+			content: "[[\"id\",\"1\"],[\"sort\",\"name\"],[\"id\",\"2\"]]",
+			encoded: "id=1&sort=name&id=2",
+		},
+		{
+			name:    "objects",
+			content: "[{\"key\":\"id\",\"value\":\"1\"},{\"Value\":\"2\",\"Key\":\"id\"}]",
+			encoded: "id=1&id=2",
+		},
+		{
+			name:    "escaped",
+			content: "{\"q\":\"a b&c=d\"}",
+			encoded: "q=a+b%26c%3Dd",
+		},
+		{
+			name:    "string",
+			content: "\"{\\\"b\\\":\\\"2\\\",\\\"a\\\":\\\"1\\\"}\"",
+			encoded: "b=2&a=1",
+		},
+		{
+			name:    "empty",
+			content: "{}",
+			encoded: "",
+		},
+		{
+			name:    "null",
+			content: "null",
+			encoded: "",
+		},
+		{
+			name:    "invalid value",
+			content: "{\"id\":{\"a\":\"1\"}}",
+			invalid: true,
+		},
+		{
+			name:    "invalid pair",
+			content: "[[\"id\",\"1\",\"2\"]]",
+			invalid: true,
+		},
+		{
+			name:    "invalid object field",
+			content: "[{\"key\":\"id\",\"values\":\"1\"}]",
+			invalid: true,
+		},
+		{
+			name:    "empty key",
+			content: "[[\"\",\"1\"]]",
+			invalid: true,
+		},
+		{
+			name:    "invalid type",
+			content: "12",
+			invalid: true,
+		},
+	}
+	for _, tt := range tests {
+		content, encoded, invalid := tt.content, tt.encoded, tt.invalid
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var params URLParams
+			err := json.Unmarshal([]byte(content), &params)
+			if invalid != (err != nil) {
+				t.Fatalf("wanted error: %t, got '%v'", invalid, err)
+			}
+			if !invalid && params.Encode() != encoded {
+				t.Errorf("wanted: '%s', got '%s'", encoded, params.Encode())
+			}
+		})
+	}
+}
+
+func TestBuildEndpointURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		params   URLParams
+		url      string
+	}{
+		{
+			name:     "relative",
+			endpoint: "/users",
+			url:      "http://localhost:8080/api/users",
+		},
+		{
+			name:     "absolute",
+			endpoint: "https://example.com/users",
+			url:      "https://example.com/users",
+		},
+		{
+			name:     "parameters",
+			endpoint: "/users",
+			params:   URLParams{{Key: "sort", Value: "name"}, {Key: "id", Value: "2"}, {Key: "id", Value: "1"}},
+			url:      "http://localhost:8080/api/users?sort=name&id=2&id=1",
+		},
+		{
+			name:     "existing query",
+			endpoint: "/users?signature=a%2Fb",
+			url:      "http://localhost:8080/api/users?signature=a%2Fb",
+		},
+		{
+			name:     "existing query and parameters",
+			endpoint: "/users?signature=a%2Fb",
+			params:   URLParams{{Key: "page", Value: "2"}},
+			url:      "http://localhost:8080/api/users?signature=a%2Fb&page=2",
+		},
+		{
+			name:     "absolute with query and parameters",
+			endpoint: "https://example.com/users?a=1",
+			params:   URLParams{{Key: "b", Value: "2"}},
+			url:      "https://example.com/users?a=1&b=2",
+		},
+	}
+	client := &Client{config: &ServerConfig{Host: "http://localhost:8080/api"}}
+	for _, tt := range tests {
+		request, url := &APIRequest{Endpoint: tt.endpoint, URLParams: tt.params}, tt.url
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := client.buildEndpointURL(context.Background(), request)
+			if err != nil {
+				t.Fatalf("wanted: '%s', got '%v'", url, err)
+			}
+			if got != url {
+				t.Errorf("wanted: '%s', got '%s'", url, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package testing
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+type junitSkipped struct{}
+
+type junitTestCase struct {
+	XMLName   xml.Name      `xml:"testcase"`
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name         `xml:"testsuite"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Errors     int               `xml:"errors,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       string            `xml:"time,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+func parseReport(report string) (string, string, error) {
+	format, filename, found := strings.Cut(report, "=")
+	if !found || filename == "" {
+		return "", "", fmt.Errorf("invalid report '%s', expected <format>=<path>", report)
+	}
+	if format != "junit" {
+		return "", "", fmt.Errorf("unsupported report format '%s'", format)
+	}
+	return format, filename, nil
+}
+
+func junitTestCaseFromResult(test *TestResult) *junitTestCase {
+	testCase := &junitTestCase{
+		Name:      test.Name,
+		ClassName: strings.TrimSuffix(test.File, ".test.json"),
+		Time:      fmt.Sprintf("%0.3f", test.Duration.Seconds()),
+	}
+	switch test.Status {
+	case StatusFail:
+		message := "test failed"
+		if test.Err != nil {
+			message = test.Err.Error()
+		}
+		testCase.Failure = &junitFailure{Message: message, Type: "failure", Content: test.details()}
+	case StatusError:
+		testCase.Error = &junitFailure{Message: test.Err.Error(), Type: "error", Content: test.details()}
+	case StatusSkip:
+		testCase.Skipped = &junitSkipped{}
+	}
+	return testCase
+}
+
+func writeJUnitReport(filename string, result *Result) error {
+	suites := &junitTestSuites{
+		Name:     "okapi",
+		Tests:    result.Total(),
+		Failures: result.Failed,
+		Errors:   result.Errored,
+		Skipped:  result.Skipped,
+		Time:     fmt.Sprintf("%0.3f", result.Duration.Seconds()),
+	}
+	for _, file := range result.Files {
+		suite := &junitTestSuite{
+			Name:     file.Name,
+			Tests:    len(file.Tests),
+			Failures: file.Failed,
+			Errors:   file.Errored,
+			Skipped:  file.Skipped,
+			Time:     fmt.Sprintf("%0.3f", file.Duration.Seconds()),
+		}
+		for _, test := range file.Tests {
+			suite.TestCases = append(suite.TestCases, junitTestCaseFromResult(test))
+		}
+		suites.TestSuites = append(suites.TestSuites, suite)
+	}
+	content, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	content = append([]byte(xml.Header), content...)
+	content = append(content, '\n')
+	if err = os.WriteFile(filename, content, 0o644); err != nil {
+		return fmt.Errorf("cannot write report '%s': %w", filename, err)
+	}
+	return nil
+}
+
+// WriteReport writes the Result of a run according to the
+// provided report specification, which is in the form
+// <format>=<path>. Only the junit format is supported.
+func WriteReport(report string, result *Result) error {
+	_, filename, err := parseReport(report)
+	if err != nil {
+		return err
+	}
+	return writeJUnitReport(filename, result)
+}
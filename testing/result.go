@@ -0,0 +1,126 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status represents the outcome of a test.
+type Status string
+
+const (
+	// StatusPass is used when the test passed.
+	StatusPass Status = "pass"
+	// StatusFail is used when the test ran but did not
+	// get the expected response.
+	StatusFail Status = "fail"
+	// StatusSkip is used when the test has been skipped.
+	StatusSkip Status = "skip"
+	// StatusError is used when the test could not run.
+	StatusError Status = "error"
+)
+
+// TestResult contains the outcome of a single test.
+type TestResult struct {
+	// Name represents the name of the test.
+	Name string
+	// File represents the test file the test belongs to.
+	File string
+	// Status represents the outcome of the test.
+	Status Status
+	// Duration represents the time taken by the test.
+	Duration time.Duration
+	// Expected represents the expected response.
+	Expected *APIResponse
+	// Response represents the response returned by the
+	// server (may be empty if the test could not run).
+	Response *APIResponse
+	// Err represents the error which made the test fail,
+	// if any.
+	Err error
+}
+
+// FileResult contains the outcome of all the tests in a
+// test file.
+type FileResult struct {
+	// Name represents the name of the test file.
+	Name string
+	// Tests represents the results of the tests, in
+	// the order in which they completed.
+	Tests []*TestResult
+	// Passed represents the number of tests which passed.
+	Passed int
+	// Failed represents the number of tests which failed.
+	Failed int
+	// Skipped represents the number of tests which were skipped.
+	Skipped int
+	// Errored represents the number of tests which could not run.
+	Errored int
+	// Duration represents the time taken by the test file.
+	Duration time.Duration
+}
+
+// Result contains the outcome of a whole okapi run.
+type Result struct {
+	// Files represents the results of the test files, in
+	// the order in which they completed.
+	Files []*FileResult
+	// Passed represents the total number of tests which passed.
+	Passed int
+	// Failed represents the total number of tests which failed.
+	Failed int
+	// Skipped represents the total number of tests which were skipped.
+	Skipped int
+	// Errored represents the total number of tests which could not run.
+	Errored int
+	// Duration represents the total run time.
+	Duration time.Duration
+}
+
+func (t *TestResult) details() string {
+	var wanted, got, wantedStatusCode string
+	var gotStatusCode int
+	if t.Expected != nil {
+		wanted, wantedStatusCode = t.Expected.Response, t.Expected.statusCodes()
+	}
+	if t.Response != nil {
+		got, gotStatusCode = strings.Trim(printable(t.Response.Response), "\n"), t.Response.StatusCode
+	}
+	return fmt.Sprintf("wanted: '%s' (%s), got '%s' (%d)", wanted, wantedStatusCode, got, gotStatusCode)
+}
+
+func (f *FileResult) add(test *TestResult) {
+	f.Tests = append(f.Tests, test)
+	switch test.Status {
+	case StatusPass:
+		f.Passed++
+	case StatusFail:
+		f.Failed++
+	case StatusSkip:
+		f.Skipped++
+	case StatusError:
+		f.Errored++
+	}
+}
+
+func (f *FileResult) failed() bool {
+	return f.Failed != 0 || f.Errored != 0
+}
+
+func (r *Result) add(file *FileResult) {
+	r.Files = append(r.Files, file)
+	r.Passed += file.Passed
+	r.Failed += file.Failed
+	r.Skipped += file.Skipped
+	r.Errored += file.Errored
+}
+
+// Total returns the total number of tests.
+func (r *Result) Total() int {
+	return r.Passed + r.Failed + r.Skipped + r.Errored
+}
+
+func (r *Result) failed() bool {
+	return r.Failed != 0 || r.Errored != 0
+}
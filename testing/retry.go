@@ -0,0 +1,261 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Backoff types.
+const (
+	backoffFixed       = "fixed"
+	backoffExponential = "exponential"
+)
+
+// retryOnConnection allows retrying when the request could not
+// be sent or its response could not be read (connection refused,
+// reset, timeout, etc.).
+const retryOnConnection = "connection"
+
+// defaultRetryOn represents the conditions under which a request
+// is retried by default.
+var defaultRetryOn = RetryOn{retryOnConnection, "502", "503", "504"}
+
+const (
+	defaultBackoffDelay    = time.Second
+	defaultBackoffMaxDelay = time.Minute
+)
+
+// Backoff represents the delay between two attempts of a request.
+type Backoff struct {
+	// Type represents the type of backoff: fixed (the default)
+	// or exponential, in which case the delay doubles after
+	// each attempt.
+	Type string
+	// Delay represents the delay (e.g. 500ms) before the first
+	// retry. It defaults to 1s.
+	Delay string
+	// MaxDelay represents the maximum delay between two attempts
+	// of an exponential backoff. It defaults to 1m.
+	MaxDelay string
+	// Jitter represents the proportion (between 0 and 1) of the
+	// delay which is randomly added or removed, so that several
+	// clients do not retry all at once.
+	Jitter float64
+}
+
+// RetryOn represents the conditions under which a request is
+// retried: status codes (503), status classes ("5xx") and/or
+// "connection", for connection errors and timeouts.
+//
+// In configuration and test files, status codes can either be
+// numbers or strings.
+type RetryOn []string
+
+// UnmarshalJSON decodes RetryOn from either a single condition
+// or an array of conditions.
+func (r *RetryOn) UnmarshalJSON(data []byte) error {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	if value == nil {
+		*r = nil
+		return nil
+	}
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	*r = make(RetryOn, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case json.Number:
+			*r = append(*r, v.String())
+		case string:
+			*r = append(*r, v)
+		default:
+			return fmt.Errorf("invalid retry on '%v'", value)
+		}
+	}
+	return nil
+}
+
+func (b *Backoff) validate() error {
+	switch b.Type {
+	case "", backoffFixed, backoffExponential:
+	default:
+		return fmt.Errorf("invalid backoff type '%s'", b.Type)
+	}
+	if b.Delay != "" {
+		if delay, err := time.ParseDuration(b.Delay); err != nil || delay < 0 {
+			return fmt.Errorf("invalid backoff delay '%s'", b.Delay)
+		}
+	}
+	if b.MaxDelay != "" {
+		if delay, err := time.ParseDuration(b.MaxDelay); err != nil || delay < 0 {
+			return fmt.Errorf("invalid backoff max delay '%s'", b.MaxDelay)
+		}
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		return fmt.Errorf("invalid backoff jitter: must be between 0 and 1")
+	}
+	return nil
+}
+
+// delay returns the delay to wait after the given attempt
+// (starting at 1). The backoff must have been validated.
+func (b *Backoff) delay(attempt int) time.Duration {
+	delay := defaultBackoffDelay
+	if b.Delay != "" {
+		delay, _ = time.ParseDuration(b.Delay)
+	}
+	if b.Type == backoffExponential {
+		limit := defaultBackoffMaxDelay
+		if b.MaxDelay != "" {
+			limit, _ = time.ParseDuration(b.MaxDelay)
+		}
+		// stop doubling before the duration overflows
+		for i := 1; i < attempt && delay < limit && delay <= math.MaxInt64/2; i++ {
+			delay *= 2
+		}
+		if delay > limit {
+			delay = limit
+		}
+	}
+	if b.Jitter != 0 {
+		delay += time.Duration(float64(delay) * b.Jitter * (2*rand.Float64() - 1))
+	}
+	return delay
+}
+
+func validateRetryPolicy(retries *int, retryOn RetryOn, backoff *Backoff) error {
+	if retries != nil && *retries < 0 {
+		return fmt.Errorf("invalid retries: must be positive")
+	}
+	for _, condition := range retryOn {
+		if condition == retryOnConnection {
+			continue
+		}
+		if err := validStatusCode(condition); err != nil {
+			return fmt.Errorf("invalid retry on: %w", err)
+		}
+	}
+	if backoff != nil {
+		if err := backoff.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryPolicy represents the retry policy of a request, made
+// of the test's settings, or of the server's ones by default.
+type retryPolicy struct {
+	retries int
+	retryOn RetryOn
+	backoff *Backoff
+}
+
+func (c *Client) retryPolicy(apiRequest *APIRequest) *retryPolicy {
+	policy := &retryPolicy{retryOn: defaultRetryOn, backoff: &Backoff{}}
+	if c.config.Retries != nil {
+		policy.retries = *c.config.Retries
+	}
+	if apiRequest.Retries != nil {
+		policy.retries = *apiRequest.Retries
+	}
+	if c.config.RetryOn != nil {
+		policy.retryOn = c.config.RetryOn
+	}
+	if apiRequest.RetryOn != nil {
+		policy.retryOn = apiRequest.RetryOn
+	}
+	if c.config.Backoff != nil {
+		policy.backoff = c.config.Backoff
+	}
+	if apiRequest.Backoff != nil {
+		policy.backoff = apiRequest.Backoff
+	}
+	return policy
+}
+
+// retryReason returns why the attempt should be retried, or
+// an empty string if it should not.
+func (p *retryPolicy) retryReason(response *APIResponse, err error) string {
+	for _, condition := range p.retryOn {
+		if condition == retryOnConnection {
+			// errors which occurred before sending the request
+			// (invalid URL, etc.) are not worth retrying
+			if err != nil && response.request != nil {
+				return fmt.Sprintf("connection error: %v", err)
+			}
+		} else if err == nil && matchStatusCode(condition, response.StatusCode) {
+			return statusText(response.StatusCode)
+		}
+	}
+	return ""
+}
+
+// timeout returns the timeout of a single attempt of the
+// request. The request must have been validated.
+func (c *Client) timeout(apiRequest *APIRequest) time.Duration {
+	if apiRequest.Timeout != "" {
+		timeout, _ := time.ParseDuration(apiRequest.Timeout)
+		return timeout
+	}
+	return time.Duration(c.config.Timeout) * time.Second
+}
+
+// callWithRetries calls the API, and calls it again as long as
+// the retry policy allows it. Every failed attempt is logged in
+// the response's attempts.
+func (c *Client) callWithRetries(ctx context.Context, apiRequest *APIRequest) (*APIResponse, error) {
+	policy := c.retryPolicy(apiRequest)
+	var logs, attempts []string
+	for attempt := 1; ; attempt++ {
+		response, err := c.call(ctx, apiRequest)
+		logs = append(logs, response.Logs...)
+		reason := policy.retryReason(response, err)
+		if reason == "" || attempt > policy.retries {
+			if attempt > 1 {
+				result := statusText(response.StatusCode)
+				if err != nil {
+					result = fmt.Sprintf("error: %v", err)
+				}
+				attempts = append(attempts, fmt.Sprintf("    attempt %d/%d: %s\n", attempt, policy.retries+1, result))
+			}
+			response.Logs = logs
+			response.attempts = attempts
+			if err != nil && attempt > 1 {
+				err = fmt.Errorf("%w (%d attempts)", err, attempt)
+			}
+			return response, err
+		}
+		delay := policy.backoff.delay(attempt)
+		attempts = append(attempts, fmt.Sprintf("    attempt %d/%d: %s, retrying in %s\n", attempt, policy.retries+1,
+			reason, delay.Round(time.Millisecond)))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			response.Logs = logs
+			response.attempts = attempts
+			return response, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// statusText returns the status code along with its text.
+func statusText(code int) string {
+	return strings.TrimSpace(fmt.Sprintf("%d %s", code, http.StatusText(code)))
+}
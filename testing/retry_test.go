@@ -0,0 +1,185 @@
+package testing
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff *Backoff
+		attempt int
+		delay   time.Duration
+	}{
+		{
+			name:    "default",
+			backoff: &Backoff{},
+			attempt: 3,
+			delay:   time.Second,
+		},
+		{
+			name:    "fixed",
+			backoff: &Backoff{Type: backoffFixed, Delay: "200ms"},
+			attempt: 5,
+			delay:   200 * time.Millisecond,
+		},
+		{
+			name:    "exponential first attempt",
+			backoff: &Backoff{Type: backoffExponential, Delay: "200ms"},
+			attempt: 1,
+			delay:   200 * time.Millisecond,
+		},
+		{
+			name:    "exponential",
+			backoff: &Backoff{Type: backoffExponential, Delay: "200ms"},
+			attempt: 4,
+			delay:   1600 * time.Millisecond,
+		},
+		{
+			name:    "exponential capped",
+			backoff: &Backoff{Type: backoffExponential, Delay: "200ms", MaxDelay: "1s"},
+			attempt: 4,
+			delay:   time.Second,
+		},
+		{
+			name:    "exponential default cap",
+			backoff: &Backoff{Type: backoffExponential, Delay: "10s"},
+			attempt: 10,
+			delay:   defaultBackoffMaxDelay,
+		},
+		{
+			name:    "exponential without overflow",
+			backoff: &Backoff{Type: backoffExponential, Delay: "1s", MaxDelay: "2562047h"},
+			attempt: 100,
+			delay:   (1 << 33) * time.Second,
+		},
+		{
+			name:    "max delay ignored when fixed",
+			backoff: &Backoff{Delay: "2s", MaxDelay: "1s"},
+			attempt: 3,
+			delay:   2 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		backoff, attempt, delay := tt.backoff, tt.attempt, tt.delay
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := backoff.delay(attempt); got != delay {
+				t.Errorf("wanted: '%s', got '%s'", delay, got)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff *Backoff
+		attempt int
+		lower   time.Duration
+		upper   time.Duration
+	}{
+		{
+			name:    "fixed",
+			backoff: &Backoff{Delay: "1s", Jitter: 0.2},
+			attempt: 2,
+			lower:   800 * time.Millisecond,
+			upper:   1200 * time.Millisecond,
+		},
+		{
+			name:    "exponential capped",
+			backoff: &Backoff{Type: backoffExponential, Delay: "1s", MaxDelay: "2s", Jitter: 0.5},
+			attempt: 5,
+			lower:   time.Second,
+			upper:   3 * time.Second,
+		},
+		{
+			name:    "full jitter",
+			backoff: &Backoff{Delay: "1s", Jitter: 1},
+			attempt: 1,
+			lower:   0,
+			upper:   2 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		backoff, attempt, lower, upper := tt.backoff, tt.attempt, tt.lower, tt.upper
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < 1000; i++ {
+				if got := backoff.delay(attempt); got < lower || got > upper {
+					t.Fatalf("wanted: between '%s' and '%s', got '%s'", lower, upper, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryReason(t *testing.T) {
+	sent := &http.Request{}
+	tests := []struct {
+		name     string
+		retryOn  RetryOn
+		response *APIResponse
+		err      error
+		reason   string
+	}{
+		{
+			name:     "success",
+			retryOn:  defaultRetryOn,
+			response: &APIResponse{StatusCode: 200, request: sent},
+		},
+		{
+			name:     "status code",
+			retryOn:  defaultRetryOn,
+			response: &APIResponse{StatusCode: 503, request: sent},
+			reason:   "503 Service Unavailable",
+		},
+		{
+			name:     "other status code",
+			retryOn:  defaultRetryOn,
+			response: &APIResponse{StatusCode: 500, request: sent},
+		},
+		{
+			name:     "status class",
+			retryOn:  RetryOn{"5xx"},
+			response: &APIResponse{StatusCode: 500, request: sent},
+			reason:   "500 Internal Server Error",
+		},
+		{
+			name:     "connection error",
+			retryOn:  defaultRetryOn,
+			response: &APIResponse{request: sent},
+			err:      errors.New("connection refused"),
+			reason:   "connection error: connection refused",
+		},
+		{
+			name:     "connection error not retried",
+			retryOn:  RetryOn{"503"},
+			response: &APIResponse{request: sent},
+			err:      errors.New("connection refused"),
+		},
+		{
+			name:     "request not sent",
+			retryOn:  defaultRetryOn,
+			response: &APIResponse{},
+			err:      errors.New("invalid url"),
+		},
+		{
+			name:     "no condition",
+			retryOn:  RetryOn{},
+			response: &APIResponse{StatusCode: 503, request: sent},
+		},
+	}
+	for _, tt := range tests {
+		policy, response, err, reason := &retryPolicy{retryOn: tt.retryOn}, tt.response, tt.err, tt.reason
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := policy.retryReason(response, err); got != reason {
+				t.Errorf("wanted: '%s', got '%s'", reason, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package testing
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fred1268/okapi/testing/internal/os"
 )
@@ -42,22 +43,75 @@ type ServerConfig struct {
 	// Usually something like: https://server/
 	Host string
 	// Headers represents headers to set on each request.
-	// The Headers field has meaningful default.
+	// They can be overridden (or removed, using an empty
+	// value) by the test files and by the tests.
 	Headers map[string]string
 	// Auth represents the authentication mode.
 	Auth *Authentication
 	// UserAgent represents the user agent okapi uses.
-	// The UserAgent field has meaningful default.
+	// It defaults to the --user-agent command line option.
 	UserAgent string
-	// Timeout represents the timeout used in every request.
-	// The Timeout field has a meaningful default.
+	// ContentType represents the content type of the payloads.
+	// It defaults to the --content-type command line option.
+	ContentType string
+	// Accept represents the content types accepted for the
+	// responses. It defaults to the --accept command line option.
+	Accept string
+	// Timeout represents the timeout used in every request,
+	// in seconds. It defaults to the --timeout command line
+	// option.
 	Timeout int
+	// Retries represents the default number of times a request
+	// is retried, according to RetryOn.
+	Retries *int
+	// RetryOn represents the default status codes (e.g. 503 or
+	// 5xx) and/or "connection" (for connection errors and
+	// timeouts) for which a request is retried.
+	RetryOn RetryOn
+	// Backoff represents the default delay between two attempts
+	// of a request.
+	Backoff *Backoff
+	// MaxDuration represents the default maximum time (e.g.
+	// 500ms) the server can take to respond to a test.
+	MaxDuration string
+	// defaults represents the headers whose value comes from
+	// the command line rather than from the server.
+	defaults map[string]bool
+}
+
+// applyDefaults sets the fields which are not set in the
+// servers configuration file to their command line value.
+func (s *ServerConfig) applyDefaults(cfg *Config) {
+	s.defaults = make(map[string]bool)
+	if s.UserAgent == "" {
+		s.UserAgent = cfg.UserAgent
+		s.defaults["User-Agent"] = true
+	}
+	if s.ContentType == "" {
+		s.ContentType = cfg.ContentType
+		s.defaults["Content-Type"] = true
+	}
+	if s.Accept == "" {
+		s.Accept = cfg.Accept
+		s.defaults["Accept"] = true
+	}
+	if s.Timeout == 0 {
+		s.Timeout = cfg.Timeout
+	}
 }
 
 func (s *ServerConfig) validate() error {
 	if s.Host == "" {
 		return fmt.Errorf("empty host name")
 	}
+	if s.MaxDuration != "" {
+		if _, err := time.ParseDuration(s.MaxDuration); err != nil {
+			return fmt.Errorf("invalid max duration: %w", err)
+		}
+	}
+	if err := validateRetryPolicy(s.Retries, s.RetryOn, s.Backoff); err != nil {
+		return err
+	}
 	if s.Auth != nil {
 		if s.Auth.Login != nil {
 			if err := s.Auth.Login.validate(); err != nil {
@@ -2,10 +2,10 @@ package testing
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/fred1268/okapi/testing/internal/log"
@@ -16,25 +16,32 @@ func load(ctx context.Context, cfg *Config, clients map[string]*Client, name str
 	if cfg.setupCapture == nil {
 		cfg.setupCapture = make(map[string]any)
 	}
+	filename := fmt.Sprintf("%s.test.json", name)
+	// only the test file itself is optional, not its dependencies
+	// (snapshots, @file payloads, etc.)
+	if _, err := os.Stat(path.Join(cfg.Directory, filename)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	uniqueTests := make(map[string]*APIRequest)
-	tests, err := loadTest(cfg, uniqueTests, fmt.Sprintf("%s.test.json", name))
+	tests, err := loadTest(cfg, uniqueTests, filename)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
 		return err
 	}
 	if cfg.Verbose {
 		log.Printf("--- EXEC:\t%s.test.json\n", name)
 	}
 	for _, test := range tests {
-		client := clients[test.Server]
-		if client == nil {
-			log.Fatalf("    --- FAIL:\tinvalid server '%s' for test '%s'\n", test.Server, test.Name)
-			continue
+		if clients[test.Server] == nil {
+			return fmt.Errorf("%w: invalid server '%s' for %s test '%s'", ErrInvalidServerConfiguration, test.Server,
+				name, test.Name)
 		}
+	}
+	failed := 0
+	for _, test := range tests {
+		client := clients[test.Server]
 		test.Endpoint = tos.SubstituteCapturedVariable(test.Endpoint, cfg.setupCapture)
 		test.Payload = tos.SubstituteCapturedVariable(test.Payload, cfg.setupCapture)
+		test.substituteFormVariables(cfg.setupCapture)
 		test.Expected.Response = tos.SubstituteCapturedVariable(test.Expected.Response, cfg.setupCapture)
 		response, err := client.Test(ctx, test, cfg.Verbose)
 		if err != nil {
@@ -42,16 +49,26 @@ func load(ctx context.Context, cfg *Config, clients map[string]*Client, name str
 				log.Printf("    --- FAIL:\tcannot run %s test '%s': %v\n", name, test.Name, err)
 				return err
 			}
+			failed++
 			log.Printf("    --- FAIL:\t%s\n", test.Name)
-			log.Printf("    wanted: '%s' (%d), got '%s' (%d)\n", test.Expected.Response, test.Expected.StatusCode,
-				strings.Trim(response.Response, "\n"), response.StatusCode)
+			log.Printf("    wanted: '%s' (%s), got '%s' (%d)\n", test.Expected.Response, test.Expected.statusCodes(),
+				strings.Trim(printable(response.Response), "\n"), response.StatusCode)
+		}
+		if err == nil && cfg.UpdateSnapshots && test.Expected.snapshot && !test.Skip {
+			file, err := writeSnapshot(cfg, test, response.Response)
+			if err != nil {
+				log.Printf("    --- FAIL:\tcannot run %s test '%s': %v\n", name, test.Name, err)
+				return err
+			}
+			if cfg.Verbose {
+				log.Printf("    snapshot updated: %s\n", file)
+			}
 		}
 		if test.CaptureJWT {
 			client.captureJWT(response.Response)
 		}
 		if name == "setup" {
-			var r interface{}
-			err = json.Unmarshal([]byte(strings.ToLower(response.Response)), &r)
+			r, err := tos.UnmarshalCapture(response.Response)
 			if err != nil {
 				continue
 			}
@@ -60,11 +77,15 @@ func load(ctx context.Context, cfg *Config, clients map[string]*Client, name str
 			}
 		}
 	}
+	if failed != 0 {
+		return fmt.Errorf("%w: %d %s test(s) failed", ErrTestsFailed, failed, name)
+	}
 	return nil
 }
 
 // Setup reads the setup.test.json test file and executes all
-// the tests within the file.
+// the tests within the file. If at least one of them failed,
+// Setup returns ErrTestsFailed.
 //
 // Results of these tests are captured into a setup object and
 // thus can be accessed using `setup.testname.xxx...`.
@@ -73,7 +94,8 @@ func Setup(ctx context.Context, cfg *Config, clients map[string]*Client) error {
 }
 
 // Teardown reads the teardown.test.json test file and executes
-// all the tests within the file.
+// all the tests within the file. If at least one of them failed,
+// Teardown returns ErrTestsFailed.
 //
 // These tests should revert what has been done in setup in order
 // to make the test suite idempotent.
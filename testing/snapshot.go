@@ -0,0 +1,46 @@
+package testing
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	ijson "github.com/fred1268/okapi/testing/internal/json"
+)
+
+func snapshotFile(name string) string {
+	return fmt.Sprintf("expected/%s.json", strings.ToLower(name))
+}
+
+// readSnapshot returns the snapshot of the test. When snapshots
+// are being updated, the snapshot is empty so that the response
+// is not compared.
+func readSnapshot(cfg *Config, name string) (string, error) {
+	if cfg.UpdateSnapshots {
+		return "", nil
+	}
+	file := snapshotFile(name)
+	content, err := os.ReadFile(path.Join(cfg.Directory, file))
+	if err != nil {
+		return "", fmt.Errorf("cannot read snapshot '%s' (use --update-snapshots to create it): %w", file, err)
+	}
+	return string(content), nil
+}
+
+// writeSnapshot writes the response of the test into its snapshot,
+// pretty-printed and without its ignored values if it is JSON.
+func writeSnapshot(cfg *Config, test *APIRequest, response string) (string, error) {
+	content, err := ijson.Redact(response, test.Expected.Ignore)
+	if err != nil {
+		content = response
+	}
+	file := snapshotFile(test.Name)
+	if err = os.MkdirAll(path.Join(cfg.Directory, "expected"), 0o755); err != nil {
+		return "", fmt.Errorf("cannot create snapshot directory: %w", err)
+	}
+	if err = os.WriteFile(path.Join(cfg.Directory, file), []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("cannot write snapshot '%s': %w", file, err)
+	}
+	return file, nil
+}
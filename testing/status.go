@@ -0,0 +1,108 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// validStatusCode returns an error if pattern is neither a
+// status code (e.g. 204) nor a status class (e.g. 2xx).
+func validStatusCode(pattern string) error {
+	if len(pattern) == 3 && pattern[0] >= '1' && pattern[0] <= '5' {
+		if strings.EqualFold(pattern[1:], "xx") {
+			return nil
+		}
+		if _, err := strconv.Atoi(pattern); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid status code '%s'", pattern)
+}
+
+// matchStatusCode returns true if code matches the pattern,
+// which is either a status code (e.g. 204) or a status
+// class (e.g. 2xx).
+func matchStatusCode(pattern string, code int) bool {
+	if len(pattern) != 3 {
+		return false
+	}
+	if strings.EqualFold(pattern[1:], "xx") {
+		return code/100 == int(pattern[0]-'0')
+	}
+	return pattern == strconv.Itoa(code)
+}
+
+// UnmarshalJSON decodes an APIResponse. The statuscode field
+// can either be a number (200), a string representing a status
+// code or a status class ("2xx"), or an array of those.
+func (a *APIResponse) UnmarshalJSON(data []byte) error {
+	type apiResponse APIResponse
+	aux := struct {
+		*apiResponse
+		StatusCode json.RawMessage
+	}{apiResponse: (*apiResponse)(a)}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&aux); err != nil {
+		return err
+	}
+	if len(aux.StatusCode) == 0 {
+		return nil
+	}
+	var value any
+	decoder = json.NewDecoder(bytes.NewReader(aux.StatusCode))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	patterns := make([]string, 0, len(values))
+	for _, value := range values {
+		var pattern string
+		switch v := value.(type) {
+		case json.Number:
+			pattern = v.String()
+		case string:
+			pattern = v
+		default:
+			return fmt.Errorf("invalid status code '%v'", value)
+		}
+		if err := validStatusCode(pattern); err != nil {
+			return err
+		}
+		patterns = append(patterns, pattern)
+	}
+	if len(patterns) == 1 {
+		if code, err := strconv.Atoi(patterns[0]); err == nil {
+			a.StatusCode = code
+			return nil
+		}
+	}
+	a.StatusCodes = append(a.StatusCodes, patterns...)
+	return nil
+}
+
+func (a *APIResponse) acceptsStatusCode(code int) bool {
+	if len(a.StatusCodes) == 0 {
+		return code == a.StatusCode
+	}
+	for _, pattern := range a.StatusCodes {
+		if matchStatusCode(pattern, code) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *APIResponse) statusCodes() string {
+	if len(a.StatusCodes) == 0 {
+		return strconv.Itoa(a.StatusCode)
+	}
+	return strings.Join(a.StatusCodes, "|")
+}
@@ -0,0 +1,147 @@
+package testing
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalStatusCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		codes   string
+		invalid bool
+	}{
+		{
+			name:    "number",
+			content: "{\"statusCode\":201}",
+			codes:   "201",
+		},
+		{
+			name:    "string",
+			content: "{\"statusCode\":\"201\"}",
+			codes:   "201",
+		},
+		{
+			name:    "class",
+			content: "{\"statusCode\":\"2xx\"}",
+			codes:   "2xx",
+		},
+		{
+			name:    "upper case class",
+			content: "{\"statusCode\":\"4XX\"}",
+			codes:   "4XX",
+		},
+		{
+			name:    "array",
+			content: "{\"statusCode\":[200,\"204\",\"3xx\"]}",
+			codes:   "200|204|3xx",
+		},
+		{
+			name:    "single element array",
+			content: "{\"statusCode\":[404]}",
+			codes:   "404",
+		},
+		{
+			name:    "missing",
+			content: "{\"response\":\"ok\"}",
+			codes:   "0",
+		},
+		{
+			name:    "out of range",
+			content: "{\"statusCode\":600}",
+			invalid: true,
+		},
+		{
+			name:    "too long",
+			content: "{\"statusCode\":\"2000\"}",
+			invalid: true,
+		},
+		{
+			name:    "invalid class",
+			content: "{\"statusCode\":\"2x\"}",
+			invalid: true,
+		},
+		{
+			name:    "invalid type",
+			content: "{\"statusCode\":true}",
+			invalid: true,
+		},
+		{
+			name:    "invalid array element",
+			content: "{\"statusCode\":[200,{}]}",
+			invalid: true,
+		},
+		{
+			name:    "status codes are not decoded",
+			content: "{\"statusCodes\":[\"abc\"]}",
+			codes:   "0",
+		},
+	}
+	for _, tt := range tests {
+		content, codes, invalid := tt.content, tt.codes, tt.invalid
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var response APIResponse
+			err := json.Unmarshal([]byte(content), &response)
+			if invalid != (err != nil) {
+				t.Fatalf("wanted error: %t, got '%v'", invalid, err)
+			}
+			if !invalid && response.statusCodes() != codes {
+				t.Errorf("wanted: '%s', got '%s'", codes, response.statusCodes())
+			}
+		})
+	}
+}
+
+func TestAcceptsStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *APIResponse
+		code     int
+		accepted bool
+	}{
+		{
+			name:     "same code",
+			response: &APIResponse{StatusCode: 200},
+			code:     200,
+			accepted: true,
+		},
+		{
+			name:     "different code",
+			response: &APIResponse{StatusCode: 200},
+			code:     201,
+		},
+		{
+			name:     "class",
+			response: &APIResponse{StatusCodes: []string{"2xx"}},
+			code:     204,
+			accepted: true,
+		},
+		{
+			name:     "other class",
+			response: &APIResponse{StatusCodes: []string{"2xx"}},
+			code:     302,
+		},
+		{
+			name:     "one of the codes",
+			response: &APIResponse{StatusCodes: []string{"200", "4XX"}},
+			code:     404,
+			accepted: true,
+		},
+		{
+			name:     "longer pattern",
+			response: &APIResponse{StatusCodes: []string{"2xxx"}},
+			code:     200,
+		},
+	}
+	for _, tt := range tests {
+		response, code, accepted := tt.response, tt.code, tt.accepted
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := response.acceptsStatusCode(code); got != accepted {
+				t.Errorf("wanted: '%t', got '%t'", accepted, got)
+			}
+		})
+	}
+}
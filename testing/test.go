@@ -2,10 +2,8 @@ package testing
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
@@ -28,30 +26,68 @@ type testOut struct {
 	start     time.Time
 	fail      bool
 	logs      []string
+	result    *TestResult
 	config    *Config
 }
 
 func runOne(ctx context.Context, tin *testIn, out chan<- *testOut) (*APIResponse, error) {
 	tout := &testOut{file: tin.file, fileStart: tin.fileStart, start: tin.start, config: tin.config}
+	tout.result = &TestResult{Name: tin.test.Name, File: tin.file, Status: StatusPass, Expected: tin.test.Expected}
+	if tin.test.Skip {
+		tout.result.Status = StatusSkip
+	}
+	start := time.Now()
 	response, err := tin.client.Test(ctx, tin.test, tin.config.Verbose)
+	tout.result.Duration = time.Since(start)
+	tout.result.Response = response
+	tout.result.Err = err
 	if err != nil {
 		if !errors.Is(err, ErrStatusCodeMismatched) && !errors.Is(err, ErrResponseMismatched) {
 			tout.fail = true
+			tout.result.Status = StatusError
 			tout.logs = append(tout.logs, fmt.Sprintf("    --- FAIL:\tcannot run test '%s' from '%s': %v\n",
 				tin.test.Name, tin.file, err))
+			emitTest(tout, tin.test)
 			out <- tout
 			return response, fmt.Errorf("cannot run test '%s' from '%s': %w", tin.test.Name, tin.file, err)
 		}
 		tout.fail = true
+		tout.result.Status = StatusFail
+	} else if tin.config.UpdateSnapshots && tin.test.Expected.snapshot && !tin.test.Skip {
+		file, err := writeSnapshot(tin.config, tin.test, response.Response)
+		if err != nil {
+			tout.fail = true
+			tout.result.Status = StatusError
+			tout.result.Err = err
+			tout.logs = append(tout.logs, fmt.Sprintf("    --- FAIL:\tcannot run test '%s' from '%s': %v\n",
+				tin.test.Name, tin.file, err))
+			emitTest(tout, tin.test)
+			out <- tout
+			return response, fmt.Errorf("cannot run test '%s' from '%s': %w", tin.test.Name, tin.file, err)
+		}
+		if tin.config.Verbose {
+			response.Logs = append(response.Logs, fmt.Sprintf("    snapshot updated: %s\n", file))
+		}
 	}
 	if tin.test.CaptureJWT {
 		tin.client.captureJWT(response.Response)
 	}
 	tout.logs = append(tout.logs, response.Logs...)
+	emitTest(tout, tin.test)
 	out <- tout
 	return response, nil
 }
 
+func emitTest(tout *testOut, test *APIRequest) {
+	if tout.config.events == nil {
+		return
+	}
+	for _, line := range tout.logs {
+		tout.config.emit(&Event{Action: ActionOutput, File: tout.file, Test: test.Name, Output: line})
+	}
+	tout.config.emit(newTestEvent(tout.result, test.describePayload()))
+}
+
 func worker(ctx context.Context, in chan []*testIn, out chan *testOut, done chan bool) {
 	for {
 		select {
@@ -63,17 +99,18 @@ func worker(ctx context.Context, in chan []*testIn, out chan *testOut, done chan
 				}
 				run.test.Endpoint = os.SubstituteCapturedVariable(run.test.Endpoint, captures)
 				run.test.Payload = os.SubstituteCapturedVariable(run.test.Payload, captures)
+				run.test.substituteFormVariables(captures)
 				run.test.Expected.Response = os.SubstituteCapturedVariable(run.test.Expected.Response, captures)
 				if run.config.FileParallel {
 					run.start = time.Now()
 				}
+				run.config.emit(&Event{Action: ActionRun, File: run.file, Test: run.test.Name})
 				resp, err := runOne(ctx, run, out)
 				if err != nil {
 					continue
 				}
 				if run.test.Capture {
-					var r interface{}
-					err := json.Unmarshal([]byte(strings.ToLower(resp.Response)), &r)
+					r, err := os.UnmarshalCapture(resp.Response)
 					if err != nil {
 						continue
 					}
@@ -88,38 +125,54 @@ func worker(ctx context.Context, in chan []*testIn, out chan *testOut, done chan
 	}
 }
 
-func printer(ctx context.Context, allTests map[string][]*APIRequest, out chan *testOut, wg *sync.WaitGroup) {
+func printer(ctx context.Context, allTests map[string][]*APIRequest, out chan *testOut, result *Result,
+	wg *sync.WaitGroup) {
 	files := 0
 	fails := make(map[string]struct{})
 	counts := make(map[string]int)
 	logs := make(map[string][]string)
+	fileResults := make(map[string]*FileResult)
 	for tout := range out {
 		counts[tout.file]++
 		if tout.fail {
 			fails[tout.file] = struct{}{}
 		}
 		logs[tout.file] = append(logs[tout.file], tout.logs...)
+		if fileResults[tout.file] == nil {
+			fileResults[tout.file] = &FileResult{Name: tout.file}
+		}
+		fileResults[tout.file].add(tout.result)
 		if counts[tout.file] == len(allTests[tout.file]) {
 			lines := logs[tout.file]
+			fileResult := fileResults[tout.file]
+			fileResult.Duration = time.Since(tout.fileStart)
+			result.add(fileResult)
 			delete(logs, tout.file)
 			delete(counts, tout.file)
+			delete(fileResults, tout.file)
+			config := tout.config
 			if _, ok := fails[tout.file]; ok {
-				log.Printf("--- FAIL:\t%s\n", tout.file)
-			} else if tout.config.Verbose {
-				log.Printf("--- PASS:\t%s\n", tout.file)
+				config.printf(tout.file, "--- FAIL:\t%s\n", tout.file)
+			} else if config.Verbose {
+				config.printf(tout.file, "--- PASS:\t%s\n", tout.file)
 			}
-			for _, line := range lines {
-				log.Printf(line)
+			if config.events == nil {
+				for _, line := range lines {
+					log.Printf(line)
+				}
 			}
-			if _, ok := fails[tout.file]; !ok && tout.config.Verbose {
-				log.Printf("PASS\n")
+			if _, ok := fails[tout.file]; !ok && config.Verbose {
+				config.printf(tout.file, "PASS\n")
 			}
 			if _, ok := fails[tout.file]; ok {
-				log.Printf("FAIL \n")
-				log.Printf("FAIL\t%s\t\t\t%0.3fs\n", tout.file, time.Since(tout.fileStart).Seconds())
-				log.Printf("FAIL \n")
+				config.printf(tout.file, "FAIL \n")
+				config.printf(tout.file, "FAIL\t%s\t\t\t%0.3fs\n", tout.file, fileResult.Duration.Seconds())
+				config.printf(tout.file, "FAIL \n")
+				config.emit(&Event{Action: ActionFail, File: tout.file, Elapsed: fileResult.Duration.Seconds()})
 			} else {
-				log.Printf("ok\t%-45s\t\t%0.3fs\n", fmt.Sprintf("%s (%d tests)", tout.file, len(allTests[tout.file])), time.Since(tout.fileStart).Seconds())
+				config.printf(tout.file, "ok\t%-45s\t\t%0.3fs\n", fmt.Sprintf("%s (%d tests)", tout.file,
+					len(allTests[tout.file])), fileResult.Duration.Seconds())
+				config.emit(&Event{Action: ActionPass, File: tout.file, Elapsed: fileResult.Duration.Seconds()})
 			}
 			files++
 		}
@@ -134,17 +187,35 @@ func printer(ctx context.Context, allTests map[string][]*APIRequest, out chan *t
 //
 // The Config only requires the Servers and Tests values,
 // all other fields have reasonable defaults.
-func Run(ctx context.Context, cfg *Config) error {
+//
+// Run returns the Result of the tests. If at least one test
+// (including the setup and teardown tests) failed or could not
+// run, Run returns the Result along with
+// ErrTestsFailed, and also ErrTestsErrored if at least one test
+// could not run. Any other error means that okapi could not run
+// the tests at all.
+func Run(ctx context.Context, cfg *Config) (*Result, error) {
+	if cfg.JSON {
+		cfg.enableEvents()
+	}
 	clients, err := LoadClients(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("cannot connect to servers: %w", err)
+		return nil, fmt.Errorf("cannot connect to servers: %w", err)
 	}
 	allTests, err := LoadTests(cfg)
 	if err != nil {
-		return fmt.Errorf("cannot read tests: %w", err)
+		return nil, fmt.Errorf("cannot read tests: %w", err)
 	}
 	if len(allTests) == 0 {
-		return fmt.Errorf("no tests")
+		return nil, fmt.Errorf("no tests")
+	}
+	for key, tests := range allTests {
+		for _, test := range tests {
+			if clients[test.Server] == nil {
+				return nil, fmt.Errorf("%w: invalid server '%s' for test '%s' ('%s')", ErrInvalidServerConfiguration,
+					test.Server, test.Name, key)
+			}
+		}
 	}
 	start := time.Now()
 	out := make(chan *testOut)
@@ -158,10 +229,14 @@ func Run(ctx context.Context, cfg *Config) error {
 	for i := 0; i < workers; i++ {
 		go worker(ctx, in, out, done)
 	}
+	result := &Result{}
 	wg.Add(1)
-	go printer(ctx, allTests, out, &wg)
-	if err := Setup(ctx, cfg, clients); err != nil {
-		return err
+	go printer(ctx, allTests, out, result, &wg)
+	// failing setup and teardown tests make the run fail, but
+	// do not prevent the other tests from running
+	setupErr := Setup(ctx, cfg, clients)
+	if setupErr != nil && !errors.Is(setupErr, ErrTestsFailed) {
+		return nil, setupErr
 	}
 	for key, tests := range allTests {
 		fileStart := time.Now()
@@ -171,10 +246,6 @@ func Run(ctx context.Context, cfg *Config) error {
 			localClients[key] = value.Clone()
 		}
 		for _, test := range tests {
-			if localClients[test.Server] == nil {
-				log.Fatalf("invalid server for %s ('%s')\n", test.Name, key)
-				continue
-			}
 			tins = append(tins, &testIn{
 				file:      key,
 				test:      test,
@@ -197,13 +268,28 @@ func Run(ctx context.Context, cfg *Config) error {
 	close(done)
 	close(in)
 	close(out)
-	if err := Teardown(ctx, cfg, clients); err != nil {
-		return err
+	teardownErr := Teardown(ctx, cfg, clients)
+	if teardownErr != nil && !errors.Is(teardownErr, ErrTestsFailed) {
+		return result, teardownErr
+	}
+	result.Duration = time.Since(start)
+	cfg.printf("", "okapi total run time: %0.3fs (%d tests total)\n", result.Duration.Seconds(), result.Total())
+	failed := result.failed() || setupErr != nil || teardownErr != nil
+	if failed {
+		cfg.emit(&Event{Action: ActionFail, Elapsed: result.Duration.Seconds()})
+	} else {
+		cfg.emit(&Event{Action: ActionPass, Elapsed: result.Duration.Seconds()})
+	}
+	if cfg.Report != "" {
+		if err := WriteReport(cfg.Report, result); err != nil {
+			return result, err
+		}
+	}
+	if result.Errored != 0 {
+		return result, errors.Join(ErrTestsErrored, ErrTestsFailed)
 	}
-	count := 0
-	for _, value := range allTests {
-		count += len(value)
+	if failed {
+		return result, ErrTestsFailed
 	}
-	log.Printf("okapi total run time: %0.3fs (%d tests total)\n", time.Since(start).Seconds(), count)
-	return nil
+	return result, nil
 }
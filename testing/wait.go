@@ -0,0 +1,97 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultWaitInterval = time.Second
+
+// WaitUntil represents a polling policy: the request is sent
+// again until its response matches the expectations, or until
+// the timeout expires.
+type WaitUntil struct {
+	// Timeout represents the maximum time (e.g. 30s) to wait
+	// for the response to match the expectations.
+	Timeout string
+	// Interval represents the delay between two requests.
+	// It defaults to 1s.
+	Interval string
+}
+
+func (w *WaitUntil) validate() error {
+	timeout, err := time.ParseDuration(w.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid wait until timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("invalid wait until timeout: must be positive")
+	}
+	if w.Interval != "" {
+		interval, err := time.ParseDuration(w.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid wait until interval: %w", err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("invalid wait until interval: must be positive")
+		}
+	}
+	return nil
+}
+
+// durations returns the timeout and the interval of the
+// polling. The policy must have been validated.
+func (w *WaitUntil) durations() (time.Duration, time.Duration) {
+	timeout, _ := time.ParseDuration(w.Timeout)
+	interval := defaultWaitInterval
+	if w.Interval != "" {
+		interval, _ = time.ParseDuration(w.Interval)
+	}
+	return timeout, interval
+}
+
+// poll calls the API and checks its response until it matches
+// the expectations, or until the timeout expires, in which case
+// the error of the last check is returned. Errors which are not
+// mismatches (connection errors, etc.) stop the polling.
+func (c *Client) poll(ctx context.Context, apiRequest *APIRequest) (*APIResponse, error) {
+	timeout, interval := apiRequest.WaitUntil.durations()
+	start := time.Now()
+	var logs, attempts []string
+	for attempt := 1; ; attempt++ {
+		response, err := c.callWithRetries(ctx, apiRequest)
+		logs = append(logs, response.Logs...)
+		attempts = append(attempts, response.attempts...)
+		if err == nil {
+			err = c.check(apiRequest.Expected, response)
+		}
+		elapsed := time.Since(start)
+		if err == nil || !errors.Is(err, ErrStatusCodeMismatched) && !errors.Is(err, ErrResponseMismatched) ||
+			elapsed >= timeout {
+			result := "condition met"
+			if err != nil {
+				result = "condition not met"
+			}
+			response.Logs = logs
+			response.attempts = append(attempts, fmt.Sprintf("    wait until: %s after %d attempt(s) (%s)\n", result,
+				attempt, elapsed.Round(time.Millisecond)))
+			return response, err
+		}
+		// the last attempt is made when the timeout expires
+		delay := interval
+		if remaining := timeout - elapsed; remaining < delay {
+			delay = remaining
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			response.Logs = logs
+			response.attempts = attempts
+			return response, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}